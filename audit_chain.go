@@ -0,0 +1,118 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Store of audit event chain head(hash of last event).
+Chain continues from stored head after restart, so removed events across restarts are detected.
+Store is per process(instance), chains of instances are not merged.
+*/
+type AuditChainHeadStore interface {
+	// Returns stored head, or empty if not stored.
+	Load(ctx context.Context) (string, error)
+
+	// Store head, called per event.
+	Save(ctx context.Context, hash string) error
+}
+
+type fileAuditChainHeadStore struct {
+	path string
+}
+
+/*
+New chain head store by local file.
+Head is written to temporary file, and renamed.
+e.g.) NewFileAuditChainHeadStore("/var/log/audit.head")
+*/
+func NewFileAuditChainHeadStore(path string) AuditChainHeadStore {
+	return &fileAuditChainHeadStore{
+		path: path,
+	}
+}
+
+func (it *fileAuditChainHeadStore) Load(ctx context.Context) (string, error) {
+	body, err := os.ReadFile(it.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("audit chain head read failed: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (it *fileAuditChainHeadStore) Save(ctx context.Context, hash string) error {
+	temp, err := os.CreateTemp(filepath.Dir(it.path), filepath.Base(it.path)+".*")
+	if err != nil {
+		return fmt.Errorf("audit chain head write failed: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(temp.Name())
+	}()
+	if _, err := temp.WriteString(hash); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("audit chain head write failed: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("audit chain head write failed: %w", err)
+	}
+	if err := os.Rename(temp.Name(), it.path); err != nil {
+		return fmt.Errorf("audit chain head write failed: %w", err)
+	}
+	return nil
+}
+
+/*
+Audit event hash chain.
+Used from dispatcher goroutine only.
+*/
+type auditChain struct {
+	/*
+		HMAC key, or empty(sha256).
+	*/
+	key []byte
+
+	/*
+		Head store, or nil.
+	*/
+	store AuditChainHeadStore
+
+	head string
+}
+
+/*
+New chain, continues from stored head.
+*/
+func newAuditChain(ctx context.Context, key []byte, store AuditChainHeadStore) (*auditChain, error) {
+	result := &auditChain{
+		key:   key,
+		store: store,
+	}
+	if store != nil {
+		head, err := store.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result.head = head
+	}
+	return result, nil
+}
+
+/*
+Set PrevHash and Hash of event, and store new head.
+*/
+func (it *auditChain) append(ctx context.Context, event *AuditEvent) error {
+	event.PrevHash = it.head
+	event.Hash = event.computeHash(it.key)
+	it.head = event.Hash
+	if it.store != nil {
+		return it.store.Save(ctx, it.head)
+	}
+	return nil
+}
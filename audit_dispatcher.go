@@ -0,0 +1,138 @@
+package secure_backend
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Asynchronous and bounded audit event dispatcher.
+If queue is full, then event is dropped and counted.
+*/
+type auditDispatcher struct {
+	logger *slog.Logger
+
+	sinks []AuditSink
+
+	chain *auditChain
+
+	queue chan *AuditEvent
+
+	dropped uint64
+
+	/*
+		Guards queue send and close.
+		Events emitted after close are dropped.
+	*/
+	lock   sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+/*
+New dispatcher, events are chained by chain(or sha256 chain from empty, if nil).
+*/
+func newAuditDispatcher(sinks []AuditSink, bufferSize int, chain *auditChain, logger *slog.Logger) *auditDispatcher {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	if chain == nil {
+		chain = &auditChain{}
+	}
+	result := &auditDispatcher{
+		logger: logger,
+		sinks:  sinks,
+		chain:  chain,
+		queue:  make(chan *AuditEvent, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go result.run()
+	return result
+}
+
+func (it *auditDispatcher) run() {
+	defer close(it.done)
+
+	for event := range it.queue {
+		if err := it.chain.append(context.Background(), event); err != nil {
+			it.logger.Error("audit chain head save failed", slog.Any("error", err))
+		}
+
+		for _, sink := range it.sinks {
+			if err := sink.Write(event); err != nil {
+				it.logger.Error("audit event write failed", slog.Any("error", err))
+			}
+		}
+	}
+
+	for _, sink := range it.sinks {
+		if err := sink.Close(); err != nil {
+			it.logger.Error("audit sink close failed", slog.Any("error", err))
+		}
+	}
+}
+
+/*
+Emit event without blocking.
+*/
+func (it *auditDispatcher) emit(event *AuditEvent) {
+	it.lock.RLock()
+	defer it.lock.RUnlock()
+	if it.closed {
+		atomic.AddUint64(&it.dropped, 1)
+		return
+	}
+	select {
+	case it.queue <- event:
+	default:
+		atomic.AddUint64(&it.dropped, 1)
+	}
+}
+
+func (it *auditDispatcher) droppedEvents() uint64 {
+	return atomic.LoadUint64(&it.dropped)
+}
+
+/*
+Write all queued events, and close sinks.
+*/
+func (it *auditDispatcher) close(ctx context.Context) error {
+	it.lock.Lock()
+	if !it.closed {
+		it.closed = true
+		close(it.queue)
+	}
+	it.lock.Unlock()
+	select {
+	case <-it.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Emit audit event, if audit is enabled.
+*/
+func (it *securityContextImpl) emitAudit(ctx context.Context, event *AuditEvent) {
+	if it.audit == nil {
+		return
+	}
+	event.Time = it.now()
+	if len(event.ClientIp) == 0 {
+		event.ClientIp = ClientIpFromContext(ctx)
+	}
+	it.audit.emit(event)
+}
+
+/*
+Returns audit outcome and reason code by error.
+*/
+func getAuditOutcome(err error) (outcome string, reasonCode string) {
+	if err != nil {
+		return AuditOutcomeDenied, getErrorClass(err)
+	}
+	return AuditOutcomeAllowed, ""
+}
@@ -0,0 +1,118 @@
+package secure_backend
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDispatcher_RequestSignatureVerifier(t *testing.T) {
+	ctx := WithClientIp(context.Background(), "192.0.2.10")
+	sink := NewMemoryAuditSink()
+	owner := newSecurityContextForTest()
+	owner.nonceStore = NewMemoryNonceStore(10)
+	owner.audit = newAuditDispatcher([]AuditSink{sink}, 10, nil, owner.logger)
+	secret := []byte("secret")
+	verifier := owner.NewRequestSignatureVerifier(StaticClientSecretStore{
+		"partner": secret,
+	})
+
+	request := newSignedRequestForTest(secret, time.Now(), "nonce-1")
+	_, err := verifier.Verify(ctx, request)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, request)
+	assert.Error(t, err)
+	assert.NoError(t, owner.audit.close(context.Background()))
+
+	events := sink.Events()
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, sha512sum("partner"), events[0].Principal)
+	assert.Equal(t, metricsVerifierRequestSignature, events[0].Verifier)
+	assert.Equal(t, "hmac", events[0].Method)
+	assert.Equal(t, AuditOutcomeAllowed, events[0].Outcome)
+	assert.Equal(t, "192.0.2.10", events[0].ClientIp)
+	assert.Equal(t, AuditOutcomeDenied, events[1].Outcome)
+	assert.Equal(t, "replayed_request", events[1].ReasonCode)
+	assert.Equal(t, uint64(0), owner.DroppedAuditEvents())
+
+	// hash chain
+	assert.Equal(t, "", events[0].PrevHash)
+	assert.Equal(t, events[0].Hash, events[1].PrevHash)
+	assert.NoError(t, VerifyAuditEventChain(events))
+
+	tampered := *events[1]
+	tampered.Outcome = AuditOutcomeAllowed
+	assert.Error(t, VerifyAuditEventChain([]*AuditEvent{events[0], &tampered}))
+	assert.Error(t, VerifyAuditEventChain([]*AuditEvent{events[1], events[0]}))
+}
+
+func TestAuditDispatcher_Drop(t *testing.T) {
+	// not started, queue is never consumed.
+	dispatcher := &auditDispatcher{
+		queue: make(chan *AuditEvent, 1),
+	}
+	dispatcher.emit(&AuditEvent{})
+	dispatcher.emit(&AuditEvent{})
+	dispatcher.emit(&AuditEvent{})
+	assert.Equal(t, uint64(2), dispatcher.droppedEvents())
+}
+
+func TestAuditDispatcher_VerifyAfterClose(t *testing.T) {
+	ctx := context.Background()
+	sink := NewMemoryAuditSink()
+	owner := newSecurityContextForTest()
+	owner.nonceStore = NewMemoryNonceStore(10)
+	owner.audit = newAuditDispatcher([]AuditSink{sink}, 10, nil, owner.logger)
+	secret := []byte("secret")
+	verifier := owner.NewRequestSignatureVerifier(StaticClientSecretStore{
+		"partner": secret,
+	})
+
+	assert.NoError(t, owner.Close(ctx))
+	assert.NoError(t, owner.Close(ctx))
+
+	// does not panic, event is dropped.
+	_, err := verifier.Verify(ctx, newSignedRequestForTest(secret, time.Now(), "nonce-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), owner.DroppedAuditEvents())
+	assert.Empty(t, sink.Events())
+}
+
+func TestAuditDispatcher_HmacChain(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("audit key")
+	store := NewFileAuditChainHeadStore(filepath.Join(t.TempDir(), "audit.head"))
+	sink := NewMemoryAuditSink()
+	logger := newSlogLogger(&Logger{})
+
+	// first process.
+	chain, err := newAuditChain(ctx, key, store)
+	assert.NoError(t, err)
+	dispatcher := newAuditDispatcher([]AuditSink{sink}, 10, chain, logger)
+	dispatcher.emit(&AuditEvent{Outcome: AuditOutcomeAllowed})
+	dispatcher.emit(&AuditEvent{Outcome: AuditOutcomeDenied})
+	assert.NoError(t, dispatcher.close(ctx))
+
+	// restarted, chain continues from stored head.
+	restartedSink := NewMemoryAuditSink()
+	chain, err = newAuditChain(ctx, key, store)
+	assert.NoError(t, err)
+	dispatcher = newAuditDispatcher([]AuditSink{restartedSink}, 10, chain, logger)
+	dispatcher.emit(&AuditEvent{Outcome: AuditOutcomeAllowed})
+	assert.NoError(t, dispatcher.close(ctx))
+
+	events := append(sink.Events(), restartedSink.Events()...)
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, events[1].Hash, events[2].PrevHash)
+	assert.NoError(t, VerifyAuditEventChainHmac(key, events))
+
+	// rebuilt without key.
+	assert.Error(t, VerifyAuditEventChain(events))
+	assert.Error(t, VerifyAuditEventChainHmac([]byte("other key"), events))
+
+	// removed event across restart.
+	assert.Error(t, VerifyAuditEventChainHmac(key, []*AuditEvent{events[0], events[2]}))
+}
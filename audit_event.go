@@ -0,0 +1,129 @@
+package secure_backend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*
+Audit event outcomes.
+*/
+const (
+	AuditOutcomeAllowed = "allowed"
+	AuditOutcomeDenied  = "denied"
+)
+
+/*
+Authentication decision record.
+
+Events are chained by hash(Hash = HMAC-SHA256(key, PrevHash + event)),
+so removed or modified event is detected by VerifyAuditEventChainHmac().
+If HMAC key is not configured, then Hash = sha256(PrevHash + event), see VerifyAuditEventChain().
+*/
+type AuditEvent struct {
+	/*
+		Decision time.
+	*/
+	Time time.Time `json:"time"`
+
+	/*
+		Verified(or claimed) principal.
+		e.g.) Firebase uid, client id, API Key owner.
+	*/
+	Principal string `json:"principal,omitempty"`
+
+	/*
+		Verification method.
+		e.g.) "firebase", "original", "service_control", "cache", "local_file", "hmac"
+	*/
+	Method string `json:"method"`
+
+	/*
+		Verifier name.
+		e.g.) "firebase_auth", "google_api_key", "local_api_key", "request_signature"
+	*/
+	Verifier string `json:"verifier"`
+
+	/*
+		AuditOutcomeAllowed or AuditOutcomeDenied.
+	*/
+	Outcome string `json:"outcome"`
+
+	/*
+		Error class if denied.
+		e.g.) "token_expired", "invalid_signature"
+	*/
+	ReasonCode string `json:"reasonCode,omitempty"`
+
+	/*
+		Token 'iss' claim.
+	*/
+	TokenIssuer string `json:"tokenIssuer,omitempty"`
+
+	/*
+		Token 'kid' header.
+	*/
+	TokenKid string `json:"tokenKid,omitempty"`
+
+	/*
+		Client IP address.
+		see) WithClientIp()
+	*/
+	ClientIp string `json:"clientIp,omitempty"`
+
+	/*
+		Hash of previous event.
+	*/
+	PrevHash string `json:"prevHash"`
+
+	/*
+		Hash of this event.
+	*/
+	Hash string `json:"hash"`
+}
+
+/*
+Returns HMAC-SHA256 of event, or sha256 if key is empty.
+*/
+func (it *AuditEvent) computeHash(key []byte) string {
+	copied := *it
+	copied.Hash = ""
+	body, _ := json.Marshal(&copied)
+	message := append([]byte(it.PrevHash), body...)
+	if len(key) == 0 {
+		sum := sha256.Sum256(message)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Verify sha256 hash chain of events, without HMAC key.
+events must be continuous, and ordered by emitted.
+*/
+func VerifyAuditEventChain(events []*AuditEvent) error {
+	return VerifyAuditEventChainHmac(nil, events)
+}
+
+/*
+Verify HMAC-SHA256 hash chain of events.
+events must be continuous, and ordered by emitted.
+see) SecurityContextConfigs.AuditHmacKey
+*/
+func VerifyAuditEventChainHmac(key []byte, events []*AuditEvent) error {
+	for i, event := range events {
+		if i > 0 && event.PrevHash != events[i-1].Hash {
+			return fmt.Errorf("audit event chain broken at %v: prevHash mismatch", i)
+		}
+		if !hmac.Equal([]byte(event.Hash), []byte(event.computeHash(key))) {
+			return fmt.Errorf("audit event chain broken at %v: hash mismatch", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package secure_backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+/*
+Audit event destination.
+Write() is called from single goroutine.
+*/
+type AuditSink interface {
+	// Write event.
+	Write(event *AuditEvent) error
+
+	// Flush and close.
+	Close() error
+}
+
+/*
+JSON lines sink, in Cloud Logging structured format.
+see) https://cloud.google.com/logging/docs/structured-logging
+*/
+type jsonAuditSink struct {
+	writer io.Writer
+}
+
+/*
+New JSON lines sink, in Cloud Logging structured format.
+e.g.) NewJsonAuditSink(os.Stdout)
+*/
+func NewJsonAuditSink(writer io.Writer) AuditSink {
+	return &jsonAuditSink{
+		writer: writer,
+	}
+}
+
+type cloudLoggingAuditEntry struct {
+	*AuditEvent
+	Severity string            `json:"severity"`
+	Message  string            `json:"message"`
+	Labels   map[string]string `json:"logging.googleapis.com/labels"`
+}
+
+func (it *jsonAuditSink) Write(event *AuditEvent) error {
+	severity := "NOTICE"
+	if event.Outcome != AuditOutcomeAllowed {
+		severity = "WARNING"
+	}
+	body, err := json.Marshal(&cloudLoggingAuditEntry{
+		AuditEvent: event,
+		Severity:   severity,
+		Message:    fmt.Sprintf("%v %v by %v", event.Verifier, event.Outcome, event.Method),
+		Labels: map[string]string{
+			"type":     "audit",
+			"verifier": event.Verifier,
+			"outcome":  event.Outcome,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = it.writer.Write(append(body, '\n'))
+	return err
+}
+
+func (it *jsonAuditSink) Close() error {
+	return nil
+}
+
+/*
+Rotating JSON lines file sink.
+*/
+type fileAuditSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	/*
+		Opened file, or nil if reopen is failed.
+	*/
+	file *os.File
+	size int64
+}
+
+/*
+New rotating JSON lines file sink.
+If file size exceeds maxBytes, then file is renamed to "path.1", and old backups are shifted("path.1" to "path.2").
+Backups over maxBackups are removed.
+If rotation is failed, then events are appended to current file, and rotation is retried by next event.
+*/
+func NewRotatingFileAuditSink(path string, maxBytes int64, maxBackups int) (AuditSink, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("invalid audit file maxBytes(%v)", maxBytes)
+	} else if maxBackups < 0 {
+		return nil, fmt.Errorf("invalid audit file maxBackups(%v)", maxBackups)
+	}
+	result := &fileAuditSink{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := result.open(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (it *fileAuditSink) open() error {
+	file, err := os.OpenFile(it.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("audit file open failed: %w", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("audit file stat failed: %w", err)
+	}
+	it.file = file
+	it.size = stat.Size()
+	return nil
+}
+
+/*
+Rotate files, and reopen file.
+File is reopened even if rotation is failed, so next events are not lost.
+*/
+func (it *fileAuditSink) rotate() error {
+	var errs []error
+	if err := it.file.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("audit file close failed: %w", err))
+	}
+	it.file = nil
+	if err := it.rotateFiles(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := it.open(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (it *fileAuditSink) rotateFiles() error {
+	_ = os.Remove(fmt.Sprintf("%v.%v", it.path, it.maxBackups))
+	for i := it.maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%v.%v", it.path, i), fmt.Sprintf("%v.%v", it.path, i+1))
+	}
+	if it.maxBackups > 0 {
+		if err := os.Rename(it.path, it.path+".1"); err != nil {
+			return fmt.Errorf("audit file rotate failed: %w", err)
+		}
+	} else if err := os.Remove(it.path); err != nil {
+		return fmt.Errorf("audit file rotate failed: %w", err)
+	}
+	return nil
+}
+
+func (it *fileAuditSink) Write(event *AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	body = append(body, '\n')
+
+	var rotateErr error
+	if it.file == nil {
+		rotateErr = it.open()
+	} else if it.size > 0 && it.size+int64(len(body)) > it.maxBytes {
+		rotateErr = it.rotate()
+	}
+	if it.file == nil {
+		return rotateErr
+	}
+
+	n, err := it.file.Write(body)
+	it.size += int64(n)
+	return errors.Join(rotateErr, err)
+}
+
+func (it *fileAuditSink) Close() error {
+	if it.file == nil {
+		return nil
+	}
+	return it.file.Close()
+}
+
+/*
+In-memory sink, for tests.
+*/
+type MemoryAuditSink struct {
+	lock   sync.Mutex
+	events []*AuditEvent
+}
+
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{}
+}
+
+func (it *MemoryAuditSink) Write(event *AuditEvent) error {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	it.events = append(it.events, event)
+	return nil
+}
+
+func (it *MemoryAuditSink) Close() error {
+	return nil
+}
+
+// Returns all written events.
+func (it *MemoryAuditSink) Events() []*AuditEvent {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	return append([]*AuditEvent{}, it.events...)
+}
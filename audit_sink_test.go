@@ -0,0 +1,91 @@
+package secure_backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonAuditSink(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	sink := NewJsonAuditSink(buffer)
+	assert.NoError(t, sink.Write(&AuditEvent{
+		Principal:  "user",
+		Method:     "firebase",
+		Verifier:   metricsVerifierFirebaseAuth,
+		Outcome:    AuditOutcomeDenied,
+		ReasonCode: "token_expired",
+	}))
+
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &entry))
+	assert.Equal(t, "WARNING", entry["severity"])
+	assert.Equal(t, "user", entry["principal"])
+	assert.Equal(t, "token_expired", entry["reasonCode"])
+	assert.Equal(t, "audit", entry["logging.googleapis.com/labels"].(map[string]interface{})["type"])
+}
+
+func TestRotatingFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewRotatingFileAuditSink(path, 100, 1)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sink.Write(&AuditEvent{
+			Principal: "user",
+			Method:    "firebase",
+			Verifier:  metricsVerifierFirebaseAuth,
+			Outcome:   AuditOutcomeAllowed,
+		}))
+	}
+	assert.NoError(t, sink.Close())
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".1")
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".2")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotatingFileAuditSink_rotateFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewRotatingFileAuditSink(path, 100, 1)
+	assert.NoError(t, err)
+
+	// backup path is not empty directory, rename is failed.
+	assert.NoError(t, os.MkdirAll(filepath.Join(path+".1", "blocked"), 0700))
+
+	event := &AuditEvent{
+		Principal: "user",
+		Method:    "firebase",
+		Verifier:  metricsVerifierFirebaseAuth,
+		Outcome:   AuditOutcomeAllowed,
+	}
+	assert.NoError(t, sink.Write(event))
+	assert.Error(t, sink.Write(event))
+
+	// file is still writable, rotation is retried.
+	assert.NoError(t, os.RemoveAll(path+".1"))
+	assert.NoError(t, sink.Write(event))
+	assert.NoError(t, sink.Close())
+
+	body, err := os.ReadFile(path + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(body, []byte("\n")))
+	body, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(body, []byte("\n")))
+}
+
+func TestNewRotatingFileAuditSink_invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	_, err := NewRotatingFileAuditSink(path, 0, 1)
+	assert.Error(t, err)
+	_, err = NewRotatingFileAuditSink(path, 100, -1)
+	assert.Error(t, err)
+}
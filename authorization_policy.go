@@ -0,0 +1,256 @@
+package secure_backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+/*
+Authorization policy for verified principal.
+
+e.g.)
+
+	policy := AllOf(
+		RequireClaimEquals("tenant", "example"),
+		AnyOf(RequireAdmin(), RequireAnyRole("editor", "owner")),
+	)
+*/
+type Policy interface {
+	// Evaluate policy.
+	Evaluate(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision
+}
+
+/*
+Policy by function.
+*/
+type PolicyFunc func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision
+
+func (it PolicyFunc) Evaluate(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+	return it(ctx, principal)
+}
+
+/*
+Result of policy.
+*/
+type PolicyDecision struct {
+	/*
+		true if allowed.
+	*/
+	Allowed bool
+
+	/*
+		Reason of decision.
+		e.g.) "claim(tenant) is not example"
+	*/
+	Reason string
+}
+
+func allow(reason string) *PolicyDecision {
+	return &PolicyDecision{Allowed: true, Reason: reason}
+}
+
+func deny(reason string) *PolicyDecision {
+	return &PolicyDecision{Allowed: false, Reason: reason}
+}
+
+func (it *PolicyDecision) String() string {
+	if it.Allowed {
+		return fmt.Sprintf("allow(%v)", it.Reason)
+	}
+	return fmt.Sprintf("deny(%v)", it.Reason)
+}
+
+/*
+Evaluate policy, and returns error if denied.
+Returned error matches ErrPermissionDenied.
+*/
+func Authorize(ctx context.Context, policy Policy, principal VerifiedPrincipal) error {
+	decision := policy.Evaluate(ctx, principal)
+	if !decision.Allowed {
+		return newVerificationError(ErrPermissionDenied, decision.Reason, nil)
+	}
+	return nil
+}
+
+/*
+Allow all verified principal.
+*/
+func AllowAuthenticated() Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		return allow("authenticated")
+	})
+}
+
+/*
+Returns claim number as rational, for exact comparison of int and float64(JSON) values.
+*/
+func getClaimNumber(value interface{}) (*big.Rat, bool) {
+	switch value := value.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(value)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(value)), true
+	case int64:
+		return new(big.Rat).SetInt64(value), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(value)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(value)), true
+	case uint64:
+		return new(big.Rat).SetUint64(value), true
+	case float32:
+		return new(big.Rat).SetFloat64(float64(value)), !math.IsNaN(float64(value)) && !math.IsInf(float64(value), 0)
+	case float64:
+		return new(big.Rat).SetFloat64(value), !math.IsNaN(value) && !math.IsInf(value, 0)
+	case json.Number:
+		return new(big.Rat).SetString(string(value))
+	}
+	return nil, false
+}
+
+/*
+Returns true if claim equals value by type.
+Numbers are compared by value(e.g. claim 1.0 equals 1), string "1" does not equal 1.
+*/
+func claimEquals(claim interface{}, value interface{}) bool {
+	if claimNumber, ok := getClaimNumber(claim); ok {
+		valueNumber, ok := getClaimNumber(value)
+		return ok && claimNumber.Cmp(valueNumber) == 0
+	}
+	return reflect.DeepEqual(claim, value)
+}
+
+/*
+Require claim equals value.
+Values are compared by type, see claimEquals().
+*/
+func RequireClaimEquals(key string, value interface{}) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		claim, ok := principal.GetClaims()[key]
+		if !ok {
+			return deny(fmt.Sprintf("claim(%v) not found", key))
+		} else if !claimEquals(claim, value) {
+			return deny(fmt.Sprintf("claim(%v) is not %v", key, value))
+		}
+		return allow(fmt.Sprintf("claim(%v) is %v", key, value))
+	})
+}
+
+/*
+Returns roles from 'roles'(string array) or 'role'(string) claim.
+*/
+func getPrincipalRoles(principal VerifiedPrincipal) []string {
+	claims := principal.GetClaims()
+	var result []string
+	switch roles := claims["roles"].(type) {
+	case []string:
+		result = append(result, roles...)
+	case []interface{}:
+		for _, role := range roles {
+			if role, ok := role.(string); ok {
+				result = append(result, role)
+			}
+		}
+	}
+	if role, ok := claims["role"].(string); ok {
+		result = append(result, role)
+	}
+	return result
+}
+
+/*
+Require any role in 'roles'(string array) or 'role'(string) claim.
+*/
+func RequireAnyRole(roles ...string) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		for _, role := range getPrincipalRoles(principal) {
+			for _, required := range roles {
+				if role == required {
+					return allow(fmt.Sprintf("role(%v)", role))
+				}
+			}
+		}
+		return deny(fmt.Sprintf("role not in [%v]", strings.Join(roles, ",")))
+	})
+}
+
+/*
+Require 'admin' claim is true.
+*/
+func RequireAdmin() Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		if admin, ok := principal.GetClaims()["admin"].(bool); ok && admin {
+			return allow("admin")
+		}
+		return deny("not admin")
+	})
+}
+
+/*
+Require all scopes in 'scope'(space separated string) claim.
+e.g.) exchanged token, OAuth 2.0 access token
+*/
+func RequireScopes(scopes ...string) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		scope, _ := principal.GetClaims()["scope"].(string)
+		granted := strings.Fields(scope)
+		for _, required := range scopes {
+			if !containsString(granted, required) {
+				return deny(fmt.Sprintf("scope(%v) not granted", required))
+			}
+		}
+		return allow(fmt.Sprintf("scope(%v)", strings.Join(scopes, " ")))
+	})
+}
+
+/*
+Allow if all policies allowed.
+*/
+func AllOf(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		reasons := make([]string, 0, len(policies))
+		for _, policy := range policies {
+			decision := policy.Evaluate(ctx, principal)
+			if !decision.Allowed {
+				return decision
+			}
+			reasons = append(reasons, decision.Reason)
+		}
+		return allow(strings.Join(reasons, " and "))
+	})
+}
+
+/*
+Allow if any policy allowed.
+*/
+func AnyOf(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		reasons := make([]string, 0, len(policies))
+		for _, policy := range policies {
+			decision := policy.Evaluate(ctx, principal)
+			if decision.Allowed {
+				return decision
+			}
+			reasons = append(reasons, decision.Reason)
+		}
+		return deny(strings.Join(reasons, " or "))
+	})
+}
+
+/*
+Allow if policy denied.
+*/
+func Not(policy Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		decision := policy.Evaluate(ctx, principal)
+		if decision.Allowed {
+			return deny(fmt.Sprintf("not(%v)", decision.Reason))
+		}
+		return allow(fmt.Sprintf("not(%v)", decision.Reason))
+	})
+}
@@ -0,0 +1,143 @@
+package secure_backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type firebaseAuthVerifierStub struct {
+	FirebaseAuthVerifier
+	tokens map[string]*VerifiedFirebaseAuthToken
+}
+
+func (it *firebaseAuthVerifierStub) Verify(ctx context.Context, token string) (*VerifiedFirebaseAuthToken, error) {
+	if verified, ok := it.tokens[token]; ok {
+		return verified, nil
+	}
+	return nil, newVerificationError(ErrInvalidSignature, "stub", nil)
+}
+
+func newVerifiedFirebaseAuthTokenForTest(uid string, claims map[string]interface{}) *VerifiedFirebaseAuthToken {
+	return &VerifiedFirebaseAuthToken{
+		User:   &FirebaseUser{Id: uid},
+		Claims: claims,
+	}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	token := newVerifiedFirebaseAuthTokenForTest("user", map[string]interface{}{
+		"tenant": "example",
+		"roles":  []interface{}{"viewer", "editor"},
+		"admin":  false,
+	})
+
+	assert.True(t, RequireClaimEquals("tenant", "example").Evaluate(ctx, token).Allowed)
+	assert.False(t, RequireClaimEquals("tenant", "other").Evaluate(ctx, token).Allowed)
+	assert.False(t, RequireClaimEquals("plan", "pro").Evaluate(ctx, token).Allowed)
+	assert.True(t, RequireClaimEquals("admin", false).Evaluate(ctx, token).Allowed)
+	assert.False(t, RequireClaimEquals("admin", "false").Evaluate(ctx, token).Allowed)
+
+	// typed values
+	typed := newVerifiedFirebaseAuthTokenForTest("user", map[string]interface{}{
+		"level":   float64(3),
+		"number":  json.Number("9007199254740993"),
+		"tenants": []interface{}{"a"},
+	})
+	assert.True(t, RequireClaimEquals("level", 3).Evaluate(ctx, typed).Allowed)
+	assert.True(t, RequireClaimEquals("level", 3.0).Evaluate(ctx, typed).Allowed)
+	assert.False(t, RequireClaimEquals("level", "3").Evaluate(ctx, typed).Allowed)
+	assert.True(t, RequireClaimEquals("number", int64(9007199254740993)).Evaluate(ctx, typed).Allowed)
+	assert.False(t, RequireClaimEquals("number", int64(9007199254740992)).Evaluate(ctx, typed).Allowed)
+	assert.True(t, RequireClaimEquals("tenants", []interface{}{"a"}).Evaluate(ctx, typed).Allowed)
+	assert.False(t, RequireClaimEquals("tenants", "[a]").Evaluate(ctx, typed).Allowed)
+	assert.True(t, RequireAnyRole("owner", "editor").Evaluate(ctx, token).Allowed)
+	assert.False(t, RequireAnyRole("owner").Evaluate(ctx, token).Allowed)
+	assert.False(t, RequireAdmin().Evaluate(ctx, token).Allowed)
+	assert.True(t, Not(RequireAdmin()).Evaluate(ctx, token).Allowed)
+
+	decision := AllOf(
+		RequireClaimEquals("tenant", "example"),
+		AnyOf(RequireAdmin(), RequireAnyRole("owner")),
+	).Evaluate(ctx, token)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "not admin or role not in [owner]", decision.Reason)
+
+	assert.True(t, AllOf(
+		RequireClaimEquals("tenant", "example"),
+		AnyOf(RequireAdmin(), RequireAnyRole("editor")),
+	).Evaluate(ctx, token).Allowed)
+
+	err := Authorize(ctx, RequireAdmin(), token)
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+}
+
+func TestNewFirebaseAuthMiddleware(t *testing.T) {
+	verifier := &firebaseAuthVerifierStub{
+		tokens: map[string]*VerifiedFirebaseAuthToken{
+			"admin-token": newVerifiedFirebaseAuthTokenForTest("admin", map[string]interface{}{"admin": true}),
+			"user-token":  newVerifiedFirebaseAuthTokenForTest("user", map[string]interface{}{}),
+		},
+	}
+	handler := NewFirebaseAuthMiddleware(verifier, RequireAdmin())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "admin", VerifiedFirebaseAuthTokenFromContext(r.Context()).User.Id)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(authorization string) int {
+		r := httptest.NewRequest("GET", "/admin", nil)
+		if len(authorization) > 0 {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("Bearer admin-token"))
+	assert.Equal(t, http.StatusForbidden, serve("Bearer user-token"))
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer invalid-token"))
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+}
+
+func TestNewFirebaseAuthUnaryServerInterceptor(t *testing.T) {
+	verifier := &firebaseAuthVerifierStub{
+		tokens: map[string]*VerifiedFirebaseAuthToken{
+			"admin-token": newVerifiedFirebaseAuthTokenForTest("admin", map[string]interface{}{"admin": true}),
+			"user-token":  newVerifiedFirebaseAuthTokenForTest("user", map[string]interface{}{}),
+		},
+	}
+	interceptor := NewFirebaseAuthUnaryServerInterceptor(verifier, MethodPolicies{
+		"":                       AllowAuthenticated(),
+		"/example.AdminService/": RequireAdmin(),
+	})
+
+	// error detail is not returned.
+	_, err := interceptor(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid-token")),
+		nil, &grpc.UnaryServerInfo{FullMethod: "/example.UserService/Get"}, nil)
+	assert.Equal(t, "unauthenticated", status.Convert(err).Message())
+
+	call := func(token string, fullMethod string) codes.Code {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			assert.NotNil(t, VerifiedPrincipalFromContext(ctx))
+			return nil, nil
+		})
+		return status.Code(err)
+	}
+
+	assert.Equal(t, codes.OK, call("user-token", "/example.UserService/Get"))
+	assert.Equal(t, codes.PermissionDenied, call("user-token", "/example.AdminService/Delete"))
+	assert.Equal(t, codes.OK, call("admin-token", "/example.AdminService/Delete"))
+	assert.Equal(t, codes.Unauthenticated, call("invalid-token", "/example.UserService/Get"))
+}
@@ -0,0 +1,104 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+/*
+Returns request attributes for policy.
+"params" is empty, path parameters are known by router only.
+*/
+func newPolicyRequest(r *http.Request) map[string]interface{} {
+	query := map[string]interface{}{}
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			query[key] = values[0]
+		}
+	}
+	return map[string]interface{}{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"query":  query,
+		"params": map[string]interface{}{},
+	}
+}
+
+/*
+HTTP middleware for 'Authorization: Bearer <token>' header.
+Verified principal is evaluated by policy, and set to request context.
+*/
+func newBearerTokenMiddleware(verify func(ctx context.Context, token string) (VerifiedPrincipal, error), policy Policy) func(next http.Handler) http.Handler {
+	if policy == nil {
+		policy = AllowAuthenticated()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := getBearerToken(r.Header.Get("Authorization"))
+			if len(token) == 0 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			ctx := r.Context()
+			if len(ClientIpFromContext(ctx)) == 0 {
+				ctx = WithClientIp(ctx, getHttpClientIp(r))
+			}
+			if PolicyRequestFromContext(ctx) == nil {
+				ctx = WithPolicyRequest(ctx, newPolicyRequest(r))
+			}
+			verified, err := verify(ctx, token)
+			if err == nil {
+				err = Authorize(ctx, policy, verified)
+			}
+			if err != nil {
+				var stepUpErr *StepUpRequiredError
+				if errors.As(err, &stepUpErr) {
+					w.Header().Set("WWW-Authenticate", stepUpErr.WWWAuthenticate())
+				}
+				status := getHttpStatusCode(err)
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithVerifiedPrincipal(ctx, verified)))
+		})
+	}
+}
+
+/*
+HTTP middleware for OpenID Connect token.
+Same as NewFirebaseAuthMiddleware(), see VerifiedOidcTokenFromContext().
+*/
+func NewOidcMiddleware(verifier OidcVerifier, policy Policy) func(next http.Handler) http.Handler {
+	return newBearerTokenMiddleware(func(ctx context.Context, token string) (VerifiedPrincipal, error) {
+		return verifier.Verify(ctx, token)
+	}, policy)
+}
+
+/*
+HTTP middleware for GitHub Actions OIDC token.
+Same as NewFirebaseAuthMiddleware(), see VerifiedGithubActionsTokenFromContext().
+
+Any workflow in any repository can request token for your audience,
+so policy is required(all requests are denied if nil), e.g.) RequireGithubRepository().
+
+e.g.)
+
+	http.Handle("/admin/deploy", NewGithubActionsMiddleware(verifier, AllOf(
+		RequireGithubRepository("octo-org/octo-repo"),
+		RequireGithubEnvironment("production"),
+	))(deployHandler))
+*/
+func NewGithubActionsMiddleware(verifier GithubActionsVerifier, policy Policy) func(next http.Handler) http.Handler {
+	if policy == nil {
+		policy = PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+			return deny("policy is required for GitHub Actions token")
+		})
+	}
+	return newBearerTokenMiddleware(func(ctx context.Context, token string) (VerifiedPrincipal, error) {
+		return verifier.Verify(ctx, token)
+	}, policy)
+}
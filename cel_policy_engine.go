@@ -0,0 +1,183 @@
+package secure_backend
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/google/cel-go/cel"
+)
+
+/*
+Input of CEL policy.
+*/
+type CelPolicyInput struct {
+	/*
+		Verified principal, or nil.
+		e.g.) *VerifiedFirebaseAuthToken
+	*/
+	Principal VerifiedPrincipal
+
+	/*
+		Verified API Key, or nil.
+	*/
+	ApiKey *VerifiedGoogleApiKey
+
+	/*
+		Request attributes.
+		HTTP middleware sets "method", "path", "query" and empty "params".
+		"params"(e.g. path parameters) are set by router, see WithPolicyRequest().
+		e.g.) {"method": "GET", "path": "/tenants/example", "query": {"page": "2"}, "params": {"tenant": "example"}}
+	*/
+	Request map[string]interface{}
+}
+
+/*
+Policy engine by Common Expression Language.
+All expressions are compiled and type checked at NewCelPolicyEngine().
+
+Variables:
+
+  - auth.uid(string): verified user id, or empty.
+  - auth.claims(map): verified claims.
+  - auth.tenant(string): 'firebase.tenant' claim, or 'tenant' claim, or empty.
+  - api_key.consumer(string): API Key consumer project number, or empty.
+  - api_key.service(string): API Key service name, or empty.
+  - api_key.owner(string): local API Key owner, or empty.
+  - request(map): request attributes, see CelPolicyInput.Request.
+
+e.g.)
+
+	engine, err := NewCelPolicyEngine(map[string]string{
+		"tenant_pro":   `auth.tenant == request.query.tenant && auth.claims.plan == "pro"`,
+		"tenant_param": `auth.tenant == request.params.tenant`, // params are set by router.
+	})
+
+see) https://github.com/google/cel-spec
+*/
+type CelPolicyEngine struct {
+	programs map[string]cel.Program
+}
+
+func newCelEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("auth.uid", cel.StringType),
+		cel.Variable("auth.claims", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("auth.tenant", cel.StringType),
+		cel.Variable("api_key.consumer", cel.StringType),
+		cel.Variable("api_key.service", cel.StringType),
+		cel.Variable("api_key.owner", cel.StringType),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+	)
+}
+
+/*
+Compile all expressions.
+key = policy name, value = CEL expression(must returns bool).
+*/
+func NewCelPolicyEngine(expressions map[string]string) (*CelPolicyEngine, error) {
+	env, err := newCelEnv()
+	if err != nil {
+		return nil, fmt.Errorf("CEL environment init failed: %w", err)
+	}
+
+	programs := map[string]cel.Program{}
+	for name, expression := range expressions {
+		ast, issues := env.Compile(expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("CEL policy(%v) compile failed: %w", name, issues.Err())
+		} else if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("CEL policy(%v) must returns bool, but %v", name, ast.OutputType())
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("CEL policy(%v) program failed: %w", name, err)
+		}
+		programs[name] = program
+	}
+
+	return &CelPolicyEngine{
+		programs: programs,
+	}, nil
+}
+
+func (it *CelPolicyInput) activation() map[string]interface{} {
+	result := map[string]interface{}{
+		"auth.uid":         "",
+		"auth.claims":      map[string]interface{}{},
+		"auth.tenant":      "",
+		"api_key.consumer": "",
+		"api_key.service":  "",
+		"api_key.owner":    "",
+		"request":          map[string]interface{}{},
+	}
+
+	if it.Principal != nil {
+		claims := it.Principal.GetClaims()
+		result["auth.uid"] = it.Principal.GetUserId()
+		result["auth.claims"] = claims
+
+		if firebaseClaim, ok := claims["firebase"].(map[string]interface{}); ok {
+			if tenant, ok := firebaseClaim["tenant"].(string); ok {
+				result["auth.tenant"] = tenant
+			}
+		}
+		if tenant, ok := claims["tenant"].(string); ok && result["auth.tenant"] == "" {
+			result["auth.tenant"] = tenant
+		}
+	}
+
+	if it.ApiKey != nil {
+		if it.ApiKey.ConsumerProjectNumber != 0 {
+			result["api_key.consumer"] = strconv.FormatInt(it.ApiKey.ConsumerProjectNumber, 10)
+		}
+		result["api_key.service"] = it.ApiKey.ServiceName
+		result["api_key.owner"] = it.ApiKey.Owner
+	}
+
+	if it.Request != nil {
+		result["request"] = it.Request
+	}
+	return result
+}
+
+/*
+Evaluate policy by name.
+If evaluation failed(e.g. claim not found), then returns error.
+*/
+func (it *CelPolicyEngine) Evaluate(ctx context.Context, name string, input *CelPolicyInput) (*PolicyDecision, error) {
+	program, ok := it.programs[name]
+	if !ok {
+		return nil, fmt.Errorf("CEL policy(%v) not found", name)
+	}
+
+	value, _, err := program.ContextEval(ctx, input.activation())
+	if err != nil {
+		return nil, fmt.Errorf("CEL policy(%v) eval failed: %w", name, err)
+	}
+
+	if allowed, ok := value.Value().(bool); ok && allowed {
+		return allow(fmt.Sprintf("cel(%v)", name)), nil
+	}
+	return deny(fmt.Sprintf("cel(%v)", name)), nil
+}
+
+/*
+Returns policy by name.
+Request attributes and API Key are read from context, see WithPolicyRequest() and WithVerifiedGoogleApiKey().
+If evaluation failed, then denied.
+*/
+func (it *CelPolicyEngine) Policy(name string) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		decision, err := it.Evaluate(ctx, name, &CelPolicyInput{
+			Principal: principal,
+			ApiKey:    VerifiedGoogleApiKeyFromContext(ctx),
+			Request:   PolicyRequestFromContext(ctx),
+		})
+		if err != nil {
+			return deny(err.Error())
+		}
+		return decision
+	})
+}
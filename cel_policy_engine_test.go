@@ -0,0 +1,89 @@
+package secure_backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCelPolicyEngine_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewCelPolicyEngine(map[string]string{
+		"tenant_pro": `auth.tenant == request.params.tenant && auth.claims.plan == "pro"`,
+		"api_key":    `api_key.consumer == "123456" && auth.uid != ""`,
+	})
+	assert.NoError(t, err)
+
+	token := newVerifiedFirebaseAuthTokenForTest("user", map[string]interface{}{
+		"plan": "pro",
+		"firebase": map[string]interface{}{
+			"tenant": "tenant-a",
+		},
+	})
+
+	decision, err := engine.Evaluate(ctx, "tenant_pro", &CelPolicyInput{
+		Principal: token,
+		Request: map[string]interface{}{
+			"params": map[string]interface{}{"tenant": "tenant-a"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	decision, err = engine.Evaluate(ctx, "tenant_pro", &CelPolicyInput{
+		Principal: token,
+		Request: map[string]interface{}{
+			"params": map[string]interface{}{"tenant": "tenant-b"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, decision.Allowed)
+
+	// by context
+	policy := engine.Policy("api_key")
+	assert.False(t, policy.Evaluate(ctx, token).Allowed)
+	ctx = WithVerifiedGoogleApiKey(ctx, &VerifiedGoogleApiKey{ConsumerProjectNumber: 123456})
+	assert.True(t, policy.Evaluate(ctx, token).Allowed)
+
+	// eval error is denied.
+	assert.False(t, engine.Policy("tenant_pro").Evaluate(ctx, token).Allowed)
+}
+
+func TestNewCelPolicyEngine_compile_error(t *testing.T) {
+	_, err := NewCelPolicyEngine(map[string]string{
+		"type": `auth.uid == 1`,
+	})
+	assert.Error(t, err)
+
+	_, err = NewCelPolicyEngine(map[string]string{
+		"not_bool": `auth.uid`,
+	})
+	assert.Error(t, err)
+
+	_, err = NewCelPolicyEngine(map[string]string{
+		"undeclared": `unknown.value == "a"`,
+	})
+	assert.Error(t, err)
+}
+
+func TestCelPolicyEngine_newPolicyRequest(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewCelPolicyEngine(map[string]string{
+		"query":  `request.method == "GET" && request.path == "/pro" && request.query.tenant == auth.tenant`,
+		"params": `"tenant" in request.params && request.params.tenant == auth.tenant`,
+	})
+	assert.NoError(t, err)
+	token := newVerifiedFirebaseAuthTokenForTest("user", map[string]interface{}{"tenant": "tenant-a"})
+
+	ctx = WithPolicyRequest(ctx, newPolicyRequest(httptest.NewRequest(http.MethodGet, "/pro?tenant=tenant-a", nil)))
+	assert.True(t, engine.Policy("query").Evaluate(ctx, token).Allowed)
+	decision, err := engine.Evaluate(ctx, "params", &CelPolicyInput{
+		Principal: token,
+		Request:   PolicyRequestFromContext(ctx),
+	})
+	assert.NoError(t, err)
+	assert.False(t, decision.Allowed)
+}
@@ -0,0 +1,24 @@
+package secure_backend
+
+import (
+	"context"
+)
+
+/*
+Per-client secret for request signature.
+*/
+type ClientSecretStore interface {
+	// Returns client secret.
+	// If client not found, then returns (nil, nil).
+	GetClientSecret(ctx context.Context, clientId string) ([]byte, error)
+}
+
+/*
+Static client secrets.
+key = client id, value = secret.
+*/
+type StaticClientSecretStore map[string][]byte
+
+func (it StaticClientSecretStore) GetClientSecret(ctx context.Context, clientId string) ([]byte, error) {
+	return it[clientId], nil
+}
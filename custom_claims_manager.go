@@ -0,0 +1,46 @@
+package secure_backend
+
+import (
+	"context"
+	"log/slog"
+)
+
+/*
+Firebase Auth custom claims manager.
+Client is selected by Firebase project, see SecurityContextConfigs.FirebaseProjectIds.
+
+see) https://firebase.google.com/docs/auth/admin/custom-claims?hl=en
+*/
+type CustomClaimsManager interface {
+	// Set custom logger.
+	SetLogger(logger *Logger)
+
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
+	// Set custom claims schema.
+	// Claims are validated by schema before update.
+	// default = no schema(only size and reserved names are validated).
+	SetSchema(schema *CustomClaimsSchema)
+
+	// Revoke refresh tokens after claims updated.
+	// Then user must sign in again, and new ID token has new claims.
+	// default = not revoke.
+	RevokeRefreshTokensOnUpdate()
+
+	// Returns custom claims of user in Firebase project.
+	// e.g.) manager.Get(ctx, token.ProjectId, token.User.Id)
+	Get(ctx context.Context, projectId string, uid string) (map[string]interface{}, error)
+
+	// Replace all custom claims.
+	Set(ctx context.Context, projectId string, uid string, claims map[string]interface{}) error
+
+	// Merge custom claims, and returns merged claims.
+	// If value is nil, then key is removed.
+	// Merge is not atomic(Get, then Set), concurrent updates for same user may be lost.
+	Merge(ctx context.Context, projectId string, uid string, claims map[string]interface{}) (map[string]interface{}, error)
+
+	// Revoke all refresh tokens.
+	// Issued ID tokens are denied by FirebaseAuthVerifier.CheckRevoked().
+	RevokeRefreshTokens(ctx context.Context, projectId string, uid string) error
+}
@@ -0,0 +1,134 @@
+package secure_backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"firebase.google.com/go/auth"
+)
+
+/*
+Firebase Auth Admin API for custom claims.
+*auth.Client implements this interface.
+*/
+type firebaseUserClient interface {
+	GetUser(ctx context.Context, uid string) (*auth.UserRecord, error)
+	SetCustomUserClaims(ctx context.Context, uid string, customClaims map[string]interface{}) error
+	RevokeRefreshTokens(ctx context.Context, uid string) error
+}
+
+/*
+Returns client for Firebase project, see getFirebaseAuth().
+*/
+func (it *securityContextImpl) getFirebaseUserClientByProject(projectId string) (firebaseUserClient, error) {
+	client, err := it.getFirebaseAuth(projectId)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+type customClaimsManagerImpl struct {
+	/*
+		Returns client for Firebase project.
+		e.g.) securityContextImpl.getFirebaseUserClientByProject()
+	*/
+	getClient func(projectId string) (firebaseUserClient, error)
+
+	logger *slog.Logger
+
+	redaction *RedactionPolicy
+
+	/*
+		option.
+	*/
+	schema *CustomClaimsSchema
+
+	revokeRefreshTokensOnUpdate bool
+}
+
+func (it *customClaimsManagerImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
+}
+
+func (it *customClaimsManagerImpl) SetSlogLogger(logger *slog.Logger) {
+	it.logger = logger
+}
+
+func (it *customClaimsManagerImpl) SetSchema(schema *CustomClaimsSchema) {
+	it.schema = schema
+}
+
+func (it *customClaimsManagerImpl) RevokeRefreshTokensOnUpdate() {
+	it.revokeRefreshTokensOnUpdate = true
+}
+
+func (it *customClaimsManagerImpl) Get(ctx context.Context, projectId string, uid string) (map[string]interface{}, error) {
+	client, err := it.getClient(projectId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := client.GetUser(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("Firebase user(%v) load failed: %w", uid, err)
+	}
+
+	result := map[string]interface{}{}
+	for key, value := range user.CustomClaims {
+		result[key] = value
+	}
+	return result, nil
+}
+
+func (it *customClaimsManagerImpl) Set(ctx context.Context, projectId string, uid string, claims map[string]interface{}) error {
+	if err := validateCustomClaims(it.schema, claims); err != nil {
+		return err
+	}
+
+	client, err := it.getClient(projectId)
+	if err != nil {
+		return err
+	}
+	if err := client.SetCustomUserClaims(ctx, uid, claims); err != nil {
+		return fmt.Errorf("Firebase user(%v) custom claims update failed: %w", uid, err)
+	}
+	it.logger.Info("custom claims updated", it.redaction.subjectAttr(uid))
+
+	if it.revokeRefreshTokensOnUpdate {
+		return it.RevokeRefreshTokens(ctx, projectId, uid)
+	}
+	return nil
+}
+
+func (it *customClaimsManagerImpl) Merge(ctx context.Context, projectId string, uid string, claims map[string]interface{}) (map[string]interface{}, error) {
+	merged, err := it.Get(ctx, projectId, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range claims {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	if err := it.Set(ctx, projectId, uid, merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func (it *customClaimsManagerImpl) RevokeRefreshTokens(ctx context.Context, projectId string, uid string) error {
+	client, err := it.getClient(projectId)
+	if err != nil {
+		return err
+	}
+	if err := client.RevokeRefreshTokens(ctx, uid); err != nil {
+		return fmt.Errorf("Firebase user(%v) refresh token revoke failed: %w", uid, err)
+	}
+	it.logger.Info("refresh tokens revoked", it.redaction.subjectAttr(uid))
+	return nil
+}
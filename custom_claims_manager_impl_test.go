@@ -0,0 +1,116 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type firebaseUserClientStub struct {
+	claims  map[string]map[string]interface{}
+	revoked []string
+}
+
+func (it *firebaseUserClientStub) GetUser(ctx context.Context, uid string) (*auth.UserRecord, error) {
+	return &auth.UserRecord{
+		UserInfo:     &auth.UserInfo{UID: uid},
+		CustomClaims: it.claims[uid],
+	}, nil
+}
+
+func (it *firebaseUserClientStub) SetCustomUserClaims(ctx context.Context, uid string, customClaims map[string]interface{}) error {
+	it.claims[uid] = customClaims
+	return nil
+}
+
+func (it *firebaseUserClientStub) RevokeRefreshTokens(ctx context.Context, uid string) error {
+	it.revoked = append(it.revoked, uid)
+	return nil
+}
+
+func TestCustomClaimsManagerImpl_Merge(t *testing.T) {
+	ctx := context.Background()
+	client := &firebaseUserClientStub{
+		claims: map[string]map[string]interface{}{
+			"user": {"admin": false, "plan": "free"},
+		},
+	}
+	other := &firebaseUserClientStub{
+		claims: map[string]map[string]interface{}{},
+	}
+	clients := map[string]firebaseUserClient{
+		"project-a": client,
+		"project-b": other,
+	}
+	manager := &customClaimsManagerImpl{
+		getClient: func(projectId string) (firebaseUserClient, error) {
+			if client, ok := clients[projectId]; ok {
+				return client, nil
+			}
+			return nil, newVerificationError(ErrInvalidAudience, "unknown project", nil)
+		},
+		logger: newSlogLogger(&Logger{}),
+	}
+	manager.SetSchema(&CustomClaimsSchema{
+		Fields: map[string]*CustomClaimField{
+			"admin": {Type: CustomClaimBool},
+			"plan":  {Type: CustomClaimString, AllowedValues: []string{"free", "pro"}},
+			"roles": {Type: CustomClaimStringArray, AllowedValues: []string{"viewer", "editor"}},
+		},
+	})
+	manager.RevokeRefreshTokensOnUpdate()
+
+	merged, err := manager.Merge(ctx, "project-a", "user", map[string]interface{}{
+		"plan":  "pro",
+		"roles": []string{"editor"},
+		"admin": nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"plan":  "pro",
+		"roles": []string{"editor"},
+	}, merged)
+	assert.Equal(t, merged, client.claims["user"])
+	assert.Equal(t, []string{"user"}, client.revoked)
+
+	_, err = manager.Merge(ctx, "project-a", "user", map[string]interface{}{"plan": "enterprise"})
+	assert.True(t, errors.Is(err, ErrInvalidCustomClaims))
+	_, err = manager.Merge(ctx, "project-a", "user", map[string]interface{}{"unknown": "value"})
+	assert.True(t, errors.Is(err, ErrInvalidCustomClaims))
+	_, err = manager.Merge(ctx, "project-a", "user", map[string]interface{}{"admin": "true"})
+	assert.True(t, errors.Is(err, ErrInvalidCustomClaims))
+
+	// other project
+	merged, err = manager.Merge(ctx, "project-b", "user", map[string]interface{}{"plan": "free"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"plan": "free"}, other.claims["user"])
+	assert.Equal(t, []string{"user"}, other.revoked)
+	assert.Equal(t, map[string]interface{}{
+		"plan":  "pro",
+		"roles": []string{"editor"},
+	}, client.claims["user"])
+
+	_, err = manager.Merge(ctx, "project-c", "user", map[string]interface{}{"plan": "free"})
+	assert.True(t, errors.Is(err, ErrInvalidAudience))
+}
+
+func Test_validateCustomClaims(t *testing.T) {
+	assert.NoError(t, validateCustomClaims(nil, map[string]interface{}{"admin": true}))
+
+	err := validateCustomClaims(nil, map[string]interface{}{"sub": "other"})
+	assert.True(t, errors.Is(err, ErrInvalidCustomClaims))
+
+	err = validateCustomClaims(nil, map[string]interface{}{"large": strings.Repeat("a", 1000)})
+	assert.True(t, errors.Is(err, ErrInvalidCustomClaims))
+
+	err = validateCustomClaims(&CustomClaimsSchema{
+		Fields: map[string]*CustomClaimField{
+			"tenant": {Type: CustomClaimString, Required: true},
+		},
+	}, map[string]interface{}{})
+	assert.True(t, errors.Is(err, ErrInvalidCustomClaims))
+}
@@ -0,0 +1,198 @@
+package secure_backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+/*
+Custom claims are invalid.
+*/
+var ErrInvalidCustomClaims = errors.New("invalid custom claims")
+
+/*
+Max size of custom claims JSON.
+*/
+const maxCustomClaimsBytes = 1000
+
+/*
+Reserved claim names, can not be used in custom claims.
+see) https://firebase.google.com/docs/auth/admin/custom-claims?hl=en
+*/
+var reservedCustomClaims = []string{
+	"acr", "amr", "at_hash", "aud", "auth_time", "azp", "cnf", "c_hash", "exp", "firebase",
+	"iat", "iss", "jti", "nbf", "nonce", "sub",
+}
+
+type CustomClaimType int
+
+const (
+	CustomClaimString CustomClaimType = iota
+	CustomClaimBool
+	CustomClaimNumber
+	CustomClaimStringArray
+	CustomClaimObject
+)
+
+func (it CustomClaimType) String() string {
+	switch it {
+	case CustomClaimString:
+		return "string"
+	case CustomClaimBool:
+		return "bool"
+	case CustomClaimNumber:
+		return "number"
+	case CustomClaimStringArray:
+		return "string array"
+	case CustomClaimObject:
+		return "object"
+	}
+	return "unknown"
+}
+
+/*
+Custom claim field definition.
+*/
+type CustomClaimField struct {
+	/*
+		Value type.
+	*/
+	Type CustomClaimType
+
+	/*
+		true if this field is required.
+	*/
+	Required bool
+
+	/*
+		Allowed values for string or string array.
+		If empty, then all values are allowed.
+	*/
+	AllowedValues []string
+}
+
+/*
+Custom claims schema.
+
+e.g.)
+
+	&CustomClaimsSchema{
+		Fields: map[string]*CustomClaimField{
+			"admin": {Type: CustomClaimBool},
+			"roles": {Type: CustomClaimStringArray, AllowedValues: []string{"viewer", "editor"}},
+		},
+	}
+*/
+type CustomClaimsSchema struct {
+	/*
+		key = claim name.
+	*/
+	Fields map[string]*CustomClaimField
+
+	/*
+		true if fields not in schema are allowed.
+	*/
+	AllowUnknownFields bool
+}
+
+func (it *CustomClaimField) allowValue(value string) bool {
+	if len(it.AllowedValues) == 0 {
+		return true
+	}
+	for _, allowed := range it.AllowedValues {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *CustomClaimField) validate(key string, value interface{}) error {
+	switch it.Type {
+	case CustomClaimString:
+		if s, ok := value.(string); !ok {
+			return fmt.Errorf("%w: claim(%v) must be %v", ErrInvalidCustomClaims, key, it.Type)
+		} else if !it.allowValue(s) {
+			return fmt.Errorf("%w: claim(%v) value(%v) is not allowed", ErrInvalidCustomClaims, key, s)
+		}
+	case CustomClaimBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%w: claim(%v) must be %v", ErrInvalidCustomClaims, key, it.Type)
+		}
+	case CustomClaimNumber:
+		switch value.(type) {
+		case int, int32, int64, float32, float64, json.Number:
+		default:
+			return fmt.Errorf("%w: claim(%v) must be %v", ErrInvalidCustomClaims, key, it.Type)
+		}
+	case CustomClaimStringArray:
+		var values []string
+		switch array := value.(type) {
+		case []string:
+			values = array
+		case []interface{}:
+			for _, v := range array {
+				s, ok := v.(string)
+				if !ok {
+					return fmt.Errorf("%w: claim(%v) must be %v", ErrInvalidCustomClaims, key, it.Type)
+				}
+				values = append(values, s)
+			}
+		default:
+			return fmt.Errorf("%w: claim(%v) must be %v", ErrInvalidCustomClaims, key, it.Type)
+		}
+		for _, s := range values {
+			if !it.allowValue(s) {
+				return fmt.Errorf("%w: claim(%v) value(%v) is not allowed", ErrInvalidCustomClaims, key, s)
+			}
+		}
+	case CustomClaimObject:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("%w: claim(%v) must be %v", ErrInvalidCustomClaims, key, it.Type)
+		}
+	}
+	return nil
+}
+
+/*
+Validate custom claims.
+Size and reserved names are always validated, and fields are validated if schema is not nil.
+*/
+func validateCustomClaims(schema *CustomClaimsSchema, claims map[string]interface{}) error {
+	for _, reserved := range reservedCustomClaims {
+		if _, ok := claims[reserved]; ok {
+			return fmt.Errorf("%w: claim(%v) is reserved", ErrInvalidCustomClaims, reserved)
+		}
+	}
+
+	bytes, err := json.Marshal(claims)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCustomClaims, err)
+	} else if len(bytes) > maxCustomClaimsBytes {
+		return fmt.Errorf("%w: claims size(%v bytes) exceeds %v bytes", ErrInvalidCustomClaims, len(bytes), maxCustomClaimsBytes)
+	}
+
+	if schema == nil {
+		return nil
+	}
+
+	for key, field := range schema.Fields {
+		if _, ok := claims[key]; !ok && field.Required {
+			return fmt.Errorf("%w: claim(%v) is required", ErrInvalidCustomClaims, key)
+		}
+	}
+	for key, value := range claims {
+		field, ok := schema.Fields[key]
+		if !ok {
+			if !schema.AllowUnknownFields {
+				return fmt.Errorf("%w: claim(%v) is not in schema", ErrInvalidCustomClaims, key)
+			}
+			continue
+		}
+		if err := field.validate(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package secure_backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Recent verification error.
+Only error class is recorded, message may contain token or identifiers.
+*/
+type recentError struct {
+	Time     time.Time `json:"time"`
+	Verifier string    `json:"verifier"`
+	Class    string    `json:"class"`
+}
+
+/*
+Ring buffer of recent errors.
+*/
+type recentErrorBuffer struct {
+	lock   sync.Mutex
+	errors []*recentError
+	next   int
+}
+
+const recentErrorCapacity = 32
+
+/*
+Add error, nil is ignored.
+*/
+func (it *recentErrorBuffer) add(verifier string, err error) {
+	if err == nil {
+		return
+	}
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	item := &recentError{
+		Time:     time.Now(),
+		Verifier: verifier,
+		Class:    getErrorClass(err),
+	}
+	if len(it.errors) < recentErrorCapacity {
+		it.errors = append(it.errors, item)
+	} else {
+		it.errors[it.next] = item
+	}
+	it.next = (it.next + 1) % recentErrorCapacity
+}
+
+/*
+Returns recent errors, newest first.
+*/
+func (it *recentErrorBuffer) list() []*recentError {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	result := make([]*recentError, 0, len(it.errors))
+	for i := 1; i <= len(it.errors); i++ {
+		result = append(result, it.errors[(it.next-i+len(it.errors))%len(it.errors)])
+	}
+	return result
+}
+
+type publicKeyDebugState struct {
+	MetadataUrl      string    `json:"metadataUrl"`
+	LatestKid        string    `json:"latestKid,omitempty"`
+	AllKids          []string  `json:"allKids"`
+	OfflineKids      []string  `json:"offlineKids"`
+	RefreshedAt      time.Time `json:"refreshedAt"`
+	LastRefreshError string    `json:"lastRefreshError,omitempty"`
+}
+
+func (it *googlePublicKeyCache) debugState() *publicKeyDebugState {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	kids := func(keys map[string]*googlePublicKey) []string {
+		result := make([]string, 0, len(keys))
+		for kid := range keys {
+			result = append(result, kid)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	result := &publicKeyDebugState{
+		MetadataUrl: it.metadataUrl,
+		AllKids:     kids(it.allKeys),
+		OfflineKids: kids(it.offlineKeys),
+		RefreshedAt: it.refreshedAt,
+	}
+	if it.latestKey != nil {
+		result.LatestKid = it.latestKey.kid
+	}
+	if it.lastRefreshError != nil {
+		result.LastRefreshError = it.lastRefreshError.Error()
+	}
+	return result
+}
+
+/*
+State of SecurityContext, without secrets.
+*/
+type securityContextDebugState struct {
+	ProjectId      string               `json:"projectId"`
+	ServiceAccount string               `json:"serviceAccount"`
+	PublicKeys     *publicKeyDebugState `json:"publicKeys,omitempty"`
+	Caches         struct {
+		ValidApiKeys int `json:"validApiKeys"`
+		LocalApiKeys int `json:"localApiKeys"`
+	} `json:"caches"`
+	DroppedAuditEvents uint64         `json:"droppedAuditEvents"`
+	Health             *HealthReport  `json:"health"`
+	RecentErrors       []*recentError `json:"recentErrors"`
+}
+
+func (it *securityContextImpl) debugState() *securityContextDebugState {
+	result := &securityContextDebugState{
+		ProjectId:          it.gcp.projectId,
+		ServiceAccount:     it.gcp.clientEmail,
+		DroppedAuditEvents: it.DroppedAuditEvents(),
+		Health:             it.Health(),
+		RecentErrors:       it.recentErrors.list(),
+	}
+	if it.gcp.serviceAccountPublicKeys != nil {
+		result.PublicKeys = it.gcp.serviceAccountPublicKeys.debugState()
+	}
+	if it.gcp.validApiKeys != nil {
+		result.Caches.ValidApiKeys = it.gcp.validApiKeys.ItemCount()
+	}
+	if it.localApiKeys != nil {
+		result.Caches.LocalApiKeys = it.localApiKeys.count()
+	}
+	return result
+}
+
+func (it *securityContextImpl) NewDebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(it.debugState())
+	})
+}
@@ -0,0 +1,61 @@
+package secure_backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentErrorBuffer(t *testing.T) {
+	buffer := &recentErrorBuffer{}
+	buffer.add(metricsVerifierFirebaseAuth, nil)
+	assert.Equal(t, 0, len(buffer.list()))
+
+	for i := 0; i < recentErrorCapacity+2; i++ {
+		buffer.add(metricsVerifierFirebaseAuth, newVerificationError(ErrTokenExpired, "expired", nil))
+	}
+	buffer.add(metricsVerifierGoogleApiKey, newVerificationError(ErrInvalidApiKey, "invalid", nil))
+
+	errors := buffer.list()
+	assert.Equal(t, recentErrorCapacity, len(errors))
+	assert.Equal(t, metricsVerifierGoogleApiKey, errors[0].Verifier)
+	assert.Equal(t, "invalid_api_key", errors[0].Class)
+	assert.Equal(t, "token_expired", errors[1].Class)
+}
+
+func TestSecurityContextImpl_NewDebugHandler(t *testing.T) {
+	owner, _ := newLocalApiKeyVerifierForTest(t, fmt.Sprintf(`{"keys": [{"sha512": "%v", "owner": "customer"}]}`, HashLocalApiKey("key")))
+	owner.tracer = newTracer(nil)
+	owner.gcp.clientEmail = "service@example.iam.gserviceaccount.com"
+	owner.gcp.serviceAccountPublicKeys = newGooglePublicKeyCache("http://localhost/keys", owner.logger, owner.tracer, &noopMetrics{})
+	owner.gcp.serviceAccountPublicKeys.addOfflineKey(&googlePublicKey{kid: "offline"})
+
+	_, err := owner.NewGoogleApiKeyVerifier().Verify(context.Background(), "secret-api-key")
+	assert.Error(t, err)
+
+	// protected by library verifier.
+	handler := NewGoogleApiKeyMiddleware(owner.NewGoogleApiKeyVerifier())(owner.NewDebugHandler())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/security", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	owner.NewDebugHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/security", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret-api-key")
+
+	state := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	assert.Equal(t, "example", state["projectId"])
+	assert.Equal(t, "service@example.iam.gserviceaccount.com", state["serviceAccount"])
+	assert.Equal(t, "offline", state["publicKeys"].(map[string]interface{})["latestKid"])
+	assert.Equal(t, float64(1), state["caches"].(map[string]interface{})["localApiKeys"])
+	recentErrors := state["recentErrors"].([]interface{})
+	assert.Equal(t, 1, len(recentErrors))
+	assert.Equal(t, "invalid_api_key", recentErrors[0].(map[string]interface{})["class"])
+}
@@ -0,0 +1,144 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+/*
+Policy per gRPC method.
+
+key is full method name("/package.Service/Method"), or service prefix("/package.Service/"),
+or "" for default policy.
+If policy not found, then request is denied.
+*/
+type MethodPolicies map[string]Policy
+
+func (it MethodPolicies) find(fullMethod string) Policy {
+	if policy, ok := it[fullMethod]; ok {
+		return policy
+	}
+	if index := strings.LastIndex(fullMethod, "/"); index >= 0 {
+		if policy, ok := it[fullMethod[:index+1]]; ok {
+			return policy
+		}
+	}
+	return it[""]
+}
+
+/*
+Returns gRPC status code for verification(or authorization) error.
+*/
+func getGrpcStatusCode(err error) codes.Code {
+	if errors.Is(err, ErrPermissionDenied) {
+		return codes.PermissionDenied
+	} else if IsRetryableError(err) {
+		return codes.Unavailable
+	}
+	return codes.Unauthenticated
+}
+
+/*
+Returns generic status message for code.
+Error detail is not returned to client, it is logged by verifier.
+*/
+func getGrpcStatusMessage(code codes.Code) string {
+	switch code {
+	case codes.PermissionDenied:
+		return "permission denied"
+	case codes.Unavailable:
+		return "authentication unavailable"
+	default:
+		return "unauthenticated"
+	}
+}
+
+func authorizeGrpcRequest(ctx context.Context, verifier FirebaseAuthVerifier, policies MethodPolicies, fullMethod string) (context.Context, error) {
+	policy := policies.find(fullMethod)
+	if policy == nil {
+		return nil, status.Error(codes.PermissionDenied, "policy not found")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	if values := md.Get("authorization"); len(values) > 0 {
+		token = getBearerToken(values[0])
+	}
+	if len(token) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization token not found")
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil && len(ClientIpFromContext(ctx)) == 0 {
+		clientIp := p.Addr.String()
+		if host, _, err := net.SplitHostPort(clientIp); err == nil {
+			clientIp = host
+		}
+		ctx = WithClientIp(ctx, clientIp)
+	}
+
+	verified, err := verifier.Verify(ctx, token)
+	if err == nil {
+		err = Authorize(ctx, policy, verified)
+	}
+	if err != nil {
+		code := getGrpcStatusCode(err)
+		return nil, status.Error(code, getGrpcStatusMessage(code))
+	}
+	return WithVerifiedPrincipal(ctx, verified), nil
+}
+
+/*
+gRPC unary interceptor for Firebase Auth token.
+Token is read from 'authorization: Bearer <token>' metadata, and verified token is evaluated by policies.
+Verified token is set to context, see VerifiedFirebaseAuthTokenFromContext().
+
+e.g.)
+
+	grpc.NewServer(grpc.UnaryInterceptor(NewFirebaseAuthUnaryServerInterceptor(verifier, MethodPolicies{
+		"": AllowAuthenticated(),
+		"/example.AdminService/": RequireAdmin(),
+	})))
+*/
+func NewFirebaseAuthUnaryServerInterceptor(verifier FirebaseAuthVerifier, policies MethodPolicies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorizeGrpcRequest(ctx, verifier, policies, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+type authorizedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (it *authorizedServerStream) Context() context.Context {
+	return it.ctx
+}
+
+/*
+gRPC stream interceptor for Firebase Auth token.
+see) NewFirebaseAuthUnaryServerInterceptor()
+*/
+func NewFirebaseAuthStreamServerInterceptor(verifier FirebaseAuthVerifier, policies MethodPolicies) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorizeGrpcRequest(ss.Context(), verifier, policies, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedServerStream{
+			ServerStream: ss,
+			ctx:          ctx,
+		})
+	}
+}
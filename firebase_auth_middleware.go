@@ -0,0 +1,45 @@
+package secure_backend
+
+import (
+	"context"
+	"net/http"
+)
+
+/*
+HTTP middleware for Firebase Auth token.
+
+Token is read from 'Authorization: Bearer <token>' header, and verified token is evaluated by policy.
+If policy is nil, then all verified tokens are allowed.
+Verified token is set to request context, see VerifiedFirebaseAuthTokenFromContext().
+If request attributes for policy are not set(see WithPolicyRequest()), then "method", "path", "query" and empty "params" are set.
+
+  - 401: Token is invalid.
+  - 403: Denied by policy.
+  - 503: Backend unavailable.
+
+e.g.)
+
+	http.Handle("/admin/", NewFirebaseAuthMiddleware(verifier, RequireAdmin())(adminHandler))
+*/
+func NewFirebaseAuthMiddleware(verifier FirebaseAuthVerifier, policy Policy) func(next http.Handler) http.Handler {
+	return newBearerTokenMiddleware(func(ctx context.Context, token string) (VerifiedPrincipal, error) {
+		return verifier.Verify(ctx, token)
+	}, policy)
+}
+
+/*
+HTTP middleware for Firebase Auth token with step-up requirements.
+Same as NewFirebaseAuthMiddleware(), but if step-up is required, then responds 401 with 'WWW-Authenticate' challenge.
+
+e.g.)
+
+	http.Handle("/account/delete", NewFirebaseAuthStepUpMiddleware(verifier, &StepUpRequirements{
+		MaxAuthAge:   5 * time.Minute,
+		SecondFactor: "totp",
+	}, nil)(deleteHandler))
+*/
+func NewFirebaseAuthStepUpMiddleware(verifier FirebaseAuthVerifier, requirements *StepUpRequirements, policy Policy) func(next http.Handler) http.Handler {
+	return newBearerTokenMiddleware(func(ctx context.Context, token string) (VerifiedPrincipal, error) {
+		return verifier.VerifyWithStepUp(ctx, token, requirements)
+	}, policy)
+}
@@ -1,21 +1,50 @@
 package secure_backend
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 // Verifier for Firebase Auth token.
 type FirebaseAuthVerifier interface {
 	// Set custom logger.
 	SetLogger(logger *Logger)
 
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
 	// Support Original JWT Token.
 	// sub = your GCP Project
 	// default = deny.
 	AcceptOriginalToken()
 
+	// Accept original token for these audiences, e.g.) exchanged token for this service.
+	// Default audience is Firebase custom token audience, it is not accepted after audiences are set.
+	AcceptOriginalTokenAudiences(audiences ...string)
+
+	// Set original token audiences and trusted issuers, then accept original token.
+	// see) OriginalTokenConfigs
+	SetOriginalTokenConfigs(configs *OriginalTokenConfigs) error
+
+	// Set sign-in requirements for Firebase user.
+	// Violation returns SignInRequirementError.
+	SetSignInRequirements(requirements *SignInRequirements)
+
+	// Check revocation of Firebase ID token, e.g.) after CustomClaimsManager.RevokeRefreshTokens().
+	// Revoked token returns ErrTokenRevoked.
+	// Each Verify() calls Firebase Auth API, original token is not checked.
+	// default = not checked.
+	CheckRevoked()
+
 	// Verify Firebase Auth token.
 	// supported)
 	// 	- JWT: Firebase Custom Token source
 	// 	- JWT: Firebase Auth Token
 	// 		see) https://firebase.google.com/docs/auth/android/custom-auth?hl=en
 	Verify(ctx context.Context, token string) (*VerifiedFirebaseAuthToken, error)
+
+	// Verify Firebase Auth token with step-up requirements for this call.
+	// e.g.) recent login and second factor for sensitive operations.
+	// Violation returns StepUpRequiredError.
+	VerifyWithStepUp(ctx context.Context, token string, requirements *StepUpRequirements) (*VerifiedFirebaseAuthToken, error)
 }
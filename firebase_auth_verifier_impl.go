@@ -4,32 +4,56 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
 	"time"
 
+	"firebase.google.com/go/auth"
 	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type firebaseAuthVerifierImpl struct {
 	owner *securityContextImpl
 
-	logger *Logger
+	logger *slog.Logger
 
 	/*
 		option.
 	*/
 	acceptOriginalToken bool
-}
 
-func (it *firebaseAuthVerifierImpl) logInfo(msg string) {
-	it.logger.logInfo(msg)
-}
+	originalTokenAudiences []string
+
+	/*
+		Trusted original token issuers, by email.
+	*/
+	trustedIssuers map[string]*TrustedIssuer
 
-func (it *firebaseAuthVerifierImpl) logError(msg string) {
-	it.logger.logError(msg)
+	/*
+		If not empty, then original token 'aud' should be this URL.
+	*/
+	requiredAudience string
+
+	signInRequirements *SignInRequirements
+
+	/*
+		If true, then Firebase ID token is verified by VerifyIDTokenAndCheckRevoked().
+	*/
+	checkRevoked bool
 }
 
+/*
+Audience of Firebase custom token.
+*/
+const firebaseCustomTokenAudience = "https://identitytoolkit.googleapis.com/google.identity.identitytoolkit.v1.IdentityToolkit"
+
 func (it *firebaseAuthVerifierImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
+}
+
+func (it *firebaseAuthVerifierImpl) SetSlogLogger(logger *slog.Logger) {
 	it.logger = logger
 }
 
@@ -37,42 +61,135 @@ func (it *firebaseAuthVerifierImpl) AcceptOriginalToken() {
 	it.acceptOriginalToken = true
 }
 
-func (it *firebaseAuthVerifierImpl) verifyOriginalToken(token string) (*VerifiedFirebaseAuthToken, error) {
-	_, parsed, err := it.owner.gcp.serviceAccountPublicKeys.parseJwt(token)
+func (it *firebaseAuthVerifierImpl) SetSignInRequirements(requirements *SignInRequirements) {
+	it.signInRequirements = requirements
+}
+
+func (it *firebaseAuthVerifierImpl) CheckRevoked() {
+	it.checkRevoked = true
+}
+
+func (it *firebaseAuthVerifierImpl) AcceptOriginalTokenAudiences(audiences ...string) {
+	it.originalTokenAudiences = append(it.originalTokenAudiences, audiences...)
+}
+
+func (it *firebaseAuthVerifierImpl) SetOriginalTokenConfigs(configs *OriginalTokenConfigs) error {
+	if err := configs.validate(); err != nil {
+		return err
+	}
+	it.acceptOriginalToken = true
+	it.AcceptOriginalTokenAudiences(configs.Audiences...)
+	for _, issuer := range configs.TrustedIssuers {
+		if it.trustedIssuers == nil {
+			it.trustedIssuers = map[string]*TrustedIssuer{}
+		}
+		trusted := *issuer
+		it.trustedIssuers[issuer.ServiceAccount] = &trusted
+	}
+	if configs.RequireServiceAudience {
+		it.requiredAudience = configs.ServiceUrl
+	} else if len(configs.ServiceUrl) > 0 {
+		it.AcceptOriginalTokenAudiences(configs.ServiceUrl)
+	}
+	return nil
+}
+
+/*
+Returns true if token is issued by own service account, or trusted issuers.
+*/
+func (it *firebaseAuthVerifierImpl) isOriginalTokenIssuer(issuer string) bool {
+	if len(issuer) == 0 {
+		return false
+	} else if issuer == it.owner.gcp.clientEmail {
+		return true
+	}
+	_, ok := it.trustedIssuers[issuer]
+	return ok
+}
+
+/*
+Returns actors from 'act' claim, nearest actor first.
+*/
+func getTokenActors(claims map[string]interface{}) []string {
+	var result []string
+	act, _ := claims["act"].(map[string]interface{})
+	for act != nil {
+		if sub, ok := act["sub"].(string); ok {
+			result = append(result, sub)
+		}
+		act, _ = act["act"].(map[string]interface{})
+	}
+	return result
+}
+
+/*
+Returns true if 'aud' is accepted for issuer.
+If audiences are configured, then only configured audiences are accepted.
+Otherwise, Firebase custom token audience is accepted only for own service account.
+*/
+func (it *firebaseAuthVerifierImpl) verifyOriginalAudience(claims jwt.MapClaims, issuer string) bool {
+	if len(it.requiredAudience) > 0 {
+		return claims.VerifyAudience(it.requiredAudience, true)
+	}
+	if len(it.originalTokenAudiences) == 0 {
+		return issuer == it.owner.gcp.clientEmail &&
+			claims.VerifyAudience(firebaseCustomTokenAudience, true)
+	}
+	for _, audience := range it.originalTokenAudiences {
+		if claims.VerifyAudience(audience, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *firebaseAuthVerifierImpl) verifyOriginalToken(ctx context.Context, token string, issuer string) (*VerifiedFirebaseAuthToken, error) {
+	projectId := it.owner.gcp.projectId
+	var keyUrl string
+	if trusted, ok := it.trustedIssuers[issuer]; ok && issuer != it.owner.gcp.clientEmail {
+		projectId = trusted.getProjectId()
+		keyUrl = trusted.KeyUrl
+	}
+	keys := it.owner.getOriginalTokenPublicKeys(issuer, keyUrl)
+	key, parsed, err := keys.parseJwt(ctx, token)
+	if key != nil {
+		it.logger.Debug("original token public key", slog.String("kid", key.kid))
+	}
 
 	if err != nil {
-		it.logError(fmt.Sprintf("jwt.Parse error: %v", err))
-		return nil, fmt.Errorf("JWT.parse failed: %w", err)
+		return nil, err
 	} else if !parsed.Valid {
-		return nil, errors.New("invalid JWT")
+		return nil, newVerificationError(ErrMalformedToken, "invalid JWT", nil)
 	} else {
+		// 'exp', 'nbf' and 'iat' are validated by parseJwt().
 		claims := parsed.Claims.(jwt.MapClaims)
-		if err := claims.Valid(); err != nil {
-			return nil, err
-		} else if !claims.VerifyAudience("https://identitytoolkit.googleapis.com/google.identity.identitytoolkit.v1.IdentityToolkit", true) {
-			return nil, errors.New("invalid JWT.aud")
-		} else if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
-			return nil, errors.New("invalid JWT.exp")
-		} else if !claims.VerifyIssuer(it.owner.gcp.clientEmail, true) {
-			return nil, errors.New("invalid JWT.iss")
+		if !it.verifyOriginalAudience(claims, issuer) {
+			return nil, newVerificationError(ErrInvalidAudience, "invalid JWT.aud", nil)
+		} else if !claims.VerifyIssuer(issuer, true) {
+			return nil, newVerificationError(ErrInvalidIssuer, "invalid JWT.iss", nil)
 		}
 
 		allClaims := map[string]interface{}{}
 		for key, value := range claims {
 			if key == "claims" {
-				for cKey, cValue := range value.(map[string]interface{}) {
-					allClaims[cKey] = cValue
+				if values, ok := value.(map[string]interface{}); ok {
+					for cKey, cValue := range values {
+						allClaims[cKey] = cValue
+					}
 				}
 			} else {
 				allClaims[key] = value
 			}
 		}
 
-		if uid, ok := allClaims["uid"]; !ok {
-			return nil, errors.New("invalid JWT.uid")
+		if uid, ok := allClaims["uid"].(string); !ok {
+			return nil, newVerificationError(ErrMalformedToken, "invalid JWT.uid", nil)
 		} else if exp, ok := allClaims["exp"]; !ok {
-			return nil, errors.New("invalid JWT.exp")
+			return nil, newVerificationError(ErrMalformedToken, "invalid JWT.exp", nil)
 		} else {
+			// original token has scope set always, even if empty.
+			scope, _ := claims["scope"].(string)
+			scopes := append([]string{}, strings.Fields(scope)...)
 			var expTime time.Time
 			switch exp := exp.(type) {
 			case float64:
@@ -82,59 +199,206 @@ func (it *firebaseAuthVerifierImpl) verifyOriginalToken(token string) (*Verified
 				expTime = time.Unix(t, 0)
 			}
 
+			authTime, secondFactor := getAuthenticationInfo(allClaims)
 			return &VerifiedFirebaseAuthToken{
-				User: &FirebaseUser{
-					Id: uid.(string),
-				},
-				Claims:   allClaims,
-				ExpireAt: expTime,
+				User:         newFirebaseUser(uid, allClaims),
+				Claims:       allClaims,
+				ExpireAt:     expTime,
+				AuthTime:     authTime,
+				SecondFactor: secondFactor,
+				ProjectId:    projectId,
+				Scopes:       scopes,
+				Actors:       getTokenActors(allClaims),
 			}, nil
 		}
 	}
 }
 
-func (it *firebaseAuthVerifierImpl) verifyFirebaseClientToken(ctx context.Context, token string) (*VerifiedFirebaseAuthToken, error) {
-	parsed, err := it.owner.gcp.firebaseAuth.VerifyIDToken(ctx, token)
+/*
+Convert Firebase Admin SDK error to VerificationError.
+Firebase Admin SDK(v3) returns only message, so classify it by message.
+*/
+func newFirebaseAuthVerificationError(err error) error {
+	message := err.Error()
+	var urlErr *url.Error
+	contains := func(substrings ...string) bool {
+		for _, substring := range substrings {
+			if strings.Contains(message, substring) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case auth.IsIDTokenRevoked(err), contains("has been revoked"):
+		return newVerificationError(ErrTokenRevoked, "Firebase ID token revoked", err)
+	case contains("has expired"):
+		return newVerificationError(ErrTokenExpired, "Firebase ID token expired", err)
+	case contains("issued at future"):
+		return newVerificationError(ErrTokenNotValidYet, "Firebase ID token issued at future", err)
+	case contains("invalid 'aud'"):
+		return newVerificationError(ErrInvalidAudience, "Firebase ID token has invalid audience", err)
+	case contains("invalid 'iss'"):
+		return newVerificationError(ErrInvalidIssuer, "Firebase ID token has invalid issuer", err)
+	case contains("failed to verify token signature", "invalid algorithm"):
+		return newVerificationError(ErrInvalidSignature, "Firebase ID token signature validation failed", err)
+	case errors.As(err, &urlErr), contains("while retrieving public keys"):
+		return newVerificationError(ErrBackendUnavailable, "Firebase public key unavailable", err)
+	default:
+		return newVerificationError(ErrMalformedToken, "Firebase ID token is invalid", err)
+	}
+}
+
+func (it *firebaseAuthVerifierImpl) verifyFirebaseClientToken(ctx context.Context, token string, projectId string) (*VerifiedFirebaseAuthToken, error) {
+	client, err := it.owner.getFirebaseAuth(projectId)
 	if err != nil {
 		return nil, err
+	}
+	var parsed *auth.Token
+	if it.checkRevoked {
+		parsed, err = client.VerifyIDTokenAndCheckRevoked(ctx, token)
 	} else {
-		allClaims := map[string]interface{}{
-			"iss": parsed.Issuer,
-			"aud": parsed.Audience,
-			"exp": parsed.Expires,
-			"iat": parsed.IssuedAt,
-			"sub": parsed.Subject,
-			"uid": parsed.UID,
-		}
-		for key, value := range parsed.Claims {
-			allClaims[key] = value
-		}
-		return &VerifiedFirebaseAuthToken{
-			User: &FirebaseUser{
-				Id: parsed.UID,
-			},
-			Claims:   allClaims,
-			ExpireAt: time.Unix(parsed.Expires, 0),
-		}, nil
+		parsed, err = client.VerifyIDToken(ctx, token)
+	}
+	if err != nil {
+		err = newFirebaseAuthVerificationError(err)
+		it.owner.firebaseStatus.record(err)
+		return nil, err
+	}
+	it.owner.firebaseStatus.record(nil)
+	return it.newVerifiedFirebaseClientToken(parsed)
+}
+
+/*
+Returns verified token from Firebase Admin SDK result.
+'exp' and 'iat' are already checked by SDK with system clock and SDK's clock skew,
+so only lifetime limit is checked here.
+*/
+func (it *firebaseAuthVerifierImpl) newVerifiedFirebaseClientToken(parsed *auth.Token) (*VerifiedFirebaseAuthToken, error) {
+	allClaims := map[string]interface{}{
+		"iss": parsed.Issuer,
+		"aud": parsed.Audience,
+		"exp": parsed.Expires,
+		"iat": parsed.IssuedAt,
+		"sub": parsed.Subject,
+		"uid": parsed.UID,
+	}
+	for key, value := range parsed.Claims {
+		allClaims[key] = value
+	}
+	if err := it.owner.tokenTime.validateLifetime(allClaims); err != nil {
+		return nil, err
 	}
+	authTime, secondFactor := getAuthenticationInfo(allClaims)
+	return &VerifiedFirebaseAuthToken{
+		User:         newFirebaseUser(parsed.UID, allClaims),
+		Claims:       allClaims,
+		ExpireAt:     time.Unix(parsed.Expires, 0),
+		AuthTime:     authTime,
+		SecondFactor: secondFactor,
+		ProjectId:    parsed.Audience,
+	}, nil
+}
+
+/*
+Returns log level for verification error.
+*/
+func getVerificationErrorLogLevel(err error) slog.Level {
+	if IsRetryableError(err) {
+		return slog.LevelError
+	}
+	return slog.LevelWarn
 }
 
 func (it *firebaseAuthVerifierImpl) Verify(ctx context.Context, token string) (*VerifiedFirebaseAuthToken, error) {
-	parse, _ := jwt.Parse(token, nil)
-	if parse == nil {
-		return nil, errors.New("token parse error")
+	return it.verify(ctx, token, nil)
+}
+
+func (it *firebaseAuthVerifierImpl) VerifyWithStepUp(ctx context.Context, token string, requirements *StepUpRequirements) (*VerifiedFirebaseAuthToken, error) {
+	return it.verify(ctx, token, requirements)
+}
+
+func (it *firebaseAuthVerifierImpl) verify(ctx context.Context, token string, stepUp *StepUpRequirements) (result *VerifiedFirebaseAuthToken, err error) {
+	startAt := time.Now()
+	var path, kid, iss, sub string
+	ctx, span := it.owner.startSpan(ctx, "FirebaseAuthVerifier.Verify")
+	defer func() {
+		it.owner.getMetrics().ObserveVerification(metricsVerifierFirebaseAuth, getVerificationResult(err), time.Since(startAt))
+		endSpan(span, err)
+
+		it.owner.recentErrors.add(metricsVerifierFirebaseAuth, err)
+
+		outcome, reasonCode := getAuditOutcome(err)
+		it.owner.emitAudit(ctx, &AuditEvent{
+			Principal:   it.owner.redaction.identifier(sub),
+			Method:      path,
+			Verifier:    metricsVerifierFirebaseAuth,
+			Outcome:     outcome,
+			ReasonCode:  reasonCode,
+			TokenIssuer: iss,
+			TokenKid:    kid,
+		})
+	}()
+
+	parse, _, parseErr := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if parseErr != nil {
+		it.logger.Warn("token parse error", slog.String("outcome", "denied"), slog.Any("error", parseErr))
+		return nil, newVerificationError(ErrMalformedToken, "token parse error", parseErr)
 	}
 
 	claims := parse.Claims.(jwt.MapClaims)
-	sub := claims["sub"].(string)
+	sub, _ = claims["sub"].(string)
+	iss, _ = claims["iss"].(string)
+	kid, _ = parse.Header["kid"].(string)
+	aud, _ := claims["aud"].(string)
 
-	it.logInfo(fmt.Sprintf("token sub: %v", sub))
+	path = "firebase"
+	if it.acceptOriginalToken && it.isOriginalTokenIssuer(sub) {
+		path = "original"
+	}
+	logger := it.logger.With(
+		slog.String("path", path),
+		slog.String("kid", kid),
+		slog.String("aud", aud),
+		it.owner.redaction.subjectAttr(sub),
+	)
+	logger.Debug("token verify start")
+	span.SetAttributes(
+		attribute.String("path", path),
+		attribute.String("kid", kid),
+		attribute.String("aud", aud),
+	)
 
+	var verified *VerifiedFirebaseAuthToken
 	if len(sub) == 0 {
-		return nil, errors.New("invalid JWT.sub")
-	} else if it.acceptOriginalToken && sub == it.owner.gcp.clientEmail {
-		return it.verifyOriginalToken(token)
+		err = newVerificationError(ErrMalformedToken, "invalid JWT.sub", nil)
+	} else if path == "original" {
+		verified, err = it.verifyOriginalToken(ctx, token, sub)
+		if err == nil {
+			err = it.signInRequirements.checkOriginal(verified.User)
+		}
 	} else {
-		return it.verifyFirebaseClientToken(ctx, token)
+		verified, err = it.verifyFirebaseClientToken(ctx, token, aud)
+		if err == nil {
+			err = it.signInRequirements.check(verified.User)
+		}
+	}
+	if err == nil {
+		err = stepUp.check(verified, it.owner.now())
 	}
+
+	duration := time.Since(startAt)
+	if err != nil {
+		logger.Log(ctx, getVerificationErrorLogLevel(err), "token verify failed",
+			slog.Duration("duration", duration),
+			slog.String("outcome", "denied"),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.Debug("token verified",
+		slog.Duration("duration", duration),
+		slog.String("outcome", "allowed"))
+	return verified, nil
 }
@@ -7,6 +7,87 @@ type FirebaseUser struct {
 		Firebase user id.
 	*/
 	Id string
+
+	/*
+		'email' claim.
+	*/
+	Email string
+
+	/*
+		'email_verified' claim.
+	*/
+	EmailVerified bool
+
+	/*
+		'phone_number' claim.
+	*/
+	PhoneNumber string
+
+	/*
+		'name' claim.
+	*/
+	Name string
+
+	/*
+		'picture' claim.
+	*/
+	Picture string
+
+	/*
+		'firebase.sign_in_provider' claim.
+		e.g.) "password", "google.com", "anonymous", "custom"
+	*/
+	SignInProvider string
+
+	/*
+		'firebase.identities' claim, provider to identifiers.
+		e.g.) {"email": ["user@example.com"], "google.com": ["1234567890"]}
+	*/
+	Identities map[string][]string
+
+	/*
+		'firebase.tenant' claim, or empty.
+	*/
+	TenantId string
+}
+
+/*
+New user from verified claims.
+Standard Firebase claims are read if exists.
+*/
+func newFirebaseUser(uid string, claims map[string]interface{}) *FirebaseUser {
+	str := func(values map[string]interface{}, key string) string {
+		value, _ := values[key].(string)
+		return value
+	}
+
+	result := &FirebaseUser{
+		Id:          uid,
+		Email:       str(claims, "email"),
+		PhoneNumber: str(claims, "phone_number"),
+		Name:        str(claims, "name"),
+		Picture:     str(claims, "picture"),
+	}
+	result.EmailVerified, _ = claims["email_verified"].(bool)
+
+	if firebase, ok := claims["firebase"].(map[string]interface{}); ok {
+		result.SignInProvider = str(firebase, "sign_in_provider")
+		result.TenantId = str(firebase, "tenant")
+		if identities, ok := firebase["identities"].(map[string]interface{}); ok {
+			result.Identities = map[string][]string{}
+			for provider, values := range identities {
+				switch values := values.(type) {
+				case []interface{}:
+					for _, value := range values {
+						result.Identities[provider] = append(result.Identities[provider], fmt.Sprintf("%v", value))
+					}
+				case []string:
+					result.Identities[provider] = append(result.Identities[provider], values...)
+				}
+			}
+		}
+	}
+	return result
 }
 
 func (it *FirebaseUser) String() string {
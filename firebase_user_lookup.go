@@ -0,0 +1,60 @@
+package secure_backend
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Firebase user account, by Admin API.
+for data not in token.
+*/
+type FirebaseUserRecord struct {
+	Id string
+
+	Email string
+
+	EmailVerified bool
+
+	PhoneNumber string
+
+	DisplayName string
+
+	PhotoUrl string
+
+	/*
+		true if account is disabled.
+	*/
+	Disabled bool
+
+	/*
+		Linked providers.
+		e.g.) []string{"password", "google.com"}
+	*/
+	Providers []string
+
+	CreatedAt time.Time
+
+	LastSignInAt time.Time
+
+	/*
+		Tokens issued before this time are revoked.
+	*/
+	TokensValidAfter time.Time
+
+	CustomClaims map[string]interface{}
+}
+
+/*
+Cached Firebase user lookup.
+Lookup calls Admin API, so create once and reuse it.
+*/
+type FirebaseUserLookup interface {
+	// Returns user account of Firebase project.
+	// Result is cached by TTL, so it may be stale(e.g. disabled status) until TTL.
+	// e.g.) lookup.GetUser(ctx, token.ProjectId, token.User.Id)
+	GetUser(ctx context.Context, projectId string, uid string) (*FirebaseUserRecord, error)
+
+	// Remove cached user.
+	Invalidate(projectId string, uid string)
+}
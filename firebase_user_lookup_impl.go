@@ -0,0 +1,91 @@
+package secure_backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/patrickmn/go-cache"
+)
+
+type firebaseUserLookupImpl struct {
+	/*
+		Returns client for Firebase project.
+		e.g.) securityContextImpl.getFirebaseUserClientByProject()
+	*/
+	getClient func(projectId string) (firebaseUserClient, error)
+
+	users *cache.Cache
+}
+
+func (it *securityContextImpl) NewFirebaseUserLookup(ttl time.Duration) FirebaseUserLookup {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &firebaseUserLookupImpl{
+		getClient: it.getFirebaseUserClientByProject,
+		users:     cache.New(ttl, ttl),
+	}
+}
+
+func millisToTime(millis int64) time.Time {
+	if millis == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
+
+func newFirebaseUserRecord(user *auth.UserRecord) *FirebaseUserRecord {
+	result := &FirebaseUserRecord{
+		Disabled:         user.Disabled,
+		EmailVerified:    user.EmailVerified,
+		TokensValidAfter: millisToTime(user.TokensValidAfterMillis),
+		CustomClaims:     user.CustomClaims,
+	}
+	if user.UserInfo != nil {
+		result.Id = user.UID
+		result.Email = user.Email
+		result.PhoneNumber = user.PhoneNumber
+		result.DisplayName = user.DisplayName
+		result.PhotoUrl = user.PhotoURL
+	}
+	if user.UserMetadata != nil {
+		result.CreatedAt = millisToTime(user.UserMetadata.CreationTimestamp)
+		result.LastSignInAt = millisToTime(user.UserMetadata.LastLogInTimestamp)
+	}
+	for _, provider := range user.ProviderUserInfo {
+		result.Providers = append(result.Providers, provider.ProviderID)
+	}
+	return result
+}
+
+/*
+Returns cache key of user, project id has no '/'.
+*/
+func getFirebaseUserCacheKey(projectId string, uid string) string {
+	return projectId + "/" + uid
+}
+
+func (it *firebaseUserLookupImpl) GetUser(ctx context.Context, projectId string, uid string) (*FirebaseUserRecord, error) {
+	cacheKey := getFirebaseUserCacheKey(projectId, uid)
+	if cached, ok := it.users.Get(cacheKey); ok {
+		return cached.(*FirebaseUserRecord), nil
+	}
+
+	client, err := it.getClient(projectId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := client.GetUser(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("Firebase user(%v) load failed: %w", uid, err)
+	}
+	result := newFirebaseUserRecord(user)
+	it.users.SetDefault(cacheKey, result)
+	return result, nil
+}
+
+func (it *firebaseUserLookupImpl) Invalidate(projectId string, uid string) {
+	it.users.Delete(getFirebaseUserCacheKey(projectId, uid))
+}
@@ -0,0 +1,80 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingFirebaseUserClient struct {
+	firebaseUserClientStub
+	calls int
+}
+
+func (it *countingFirebaseUserClient) GetUser(ctx context.Context, uid string) (*auth.UserRecord, error) {
+	it.calls++
+	return &auth.UserRecord{
+		UserInfo:      &auth.UserInfo{UID: uid, Email: "user@example.com"},
+		Disabled:      true,
+		EmailVerified: true,
+		ProviderUserInfo: []*auth.UserInfo{
+			{ProviderID: "password"},
+			{ProviderID: "google.com"},
+		},
+		UserMetadata: &auth.UserMetadata{
+			CreationTimestamp: 1700000000000,
+		},
+	}, nil
+}
+
+func TestFirebaseUserLookupImpl_GetUser(t *testing.T) {
+	ctx := context.Background()
+	clients := map[string]*countingFirebaseUserClient{
+		"project-a": {},
+		"project-b": {},
+	}
+	client := clients["project-a"]
+	lookup := &firebaseUserLookupImpl{
+		getClient: func(projectId string) (firebaseUserClient, error) {
+			if client, ok := clients[projectId]; ok {
+				return client, nil
+			}
+			return nil, newVerificationError(ErrInvalidAudience, "not accepted", nil)
+		},
+		users: cache.New(time.Minute, time.Minute),
+	}
+
+	user, err := lookup.GetUser(ctx, "project-a", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", user.Id)
+	assert.True(t, user.Disabled)
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, []string{"password", "google.com"}, user.Providers)
+	assert.Equal(t, int64(1700000000), user.CreatedAt.Unix())
+	assert.True(t, user.LastSignInAt.IsZero())
+
+	// cached
+	_, err = lookup.GetUser(ctx, "project-a", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.calls)
+
+	// same uid in other project.
+	_, err = lookup.GetUser(ctx, "project-b", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, 1, clients["project-b"].calls)
+
+	lookup.Invalidate("project-a", "user-1")
+	_, err = lookup.GetUser(ctx, "project-a", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.calls)
+
+	// not accepted project.
+	_, err = lookup.GetUser(ctx, "project-c", "user-1")
+	assert.True(t, errors.Is(err, ErrInvalidAudience))
+}
@@ -0,0 +1,40 @@
+package secure_backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newFirebaseUser(t *testing.T) {
+	user := newFirebaseUser("user-1", map[string]interface{}{
+		"email":          "user@example.com",
+		"email_verified": true,
+		"phone_number":   "+819000000000",
+		"name":           "User",
+		"picture":        "https://example.com/user.png",
+		"firebase": map[string]interface{}{
+			"sign_in_provider": "google.com",
+			"tenant":           "tenant-1",
+			"identities": map[string]interface{}{
+				"email":      []interface{}{"user@example.com"},
+				"google.com": []interface{}{"1234567890"},
+			},
+		},
+	})
+	assert.Equal(t, "user-1", user.Id)
+	assert.Equal(t, "user@example.com", user.Email)
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, "+819000000000", user.PhoneNumber)
+	assert.Equal(t, "User", user.Name)
+	assert.Equal(t, "https://example.com/user.png", user.Picture)
+	assert.Equal(t, "google.com", user.SignInProvider)
+	assert.Equal(t, "tenant-1", user.TenantId)
+	assert.Equal(t, []string{"1234567890"}, user.Identities["google.com"])
+
+	// custom token, no standard claims.
+	user = newFirebaseUser("user-2", map[string]interface{}{"email_verified": "true"})
+	assert.Equal(t, "user-2", user.Id)
+	assert.False(t, user.EmailVerified)
+	assert.Nil(t, user.Identities)
+}
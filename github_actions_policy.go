@@ -0,0 +1,59 @@
+package secure_backend
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+/*
+Require string claim matches any pattern.
+Pattern syntax is path.Match(), e.g.) "refs/heads/*"
+*/
+func requireClaimMatches(key string, patterns []string) Policy {
+	return PolicyFunc(func(ctx context.Context, principal VerifiedPrincipal) *PolicyDecision {
+		value, ok := principal.GetClaims()[key].(string)
+		if !ok || len(value) == 0 {
+			return deny(fmt.Sprintf("claim(%v) not found", key))
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, value); matched {
+				return allow(fmt.Sprintf("claim(%v) is %v", key, value))
+			}
+		}
+		return deny(fmt.Sprintf("claim(%v) not in [%v]", key, strings.Join(patterns, ",")))
+	})
+}
+
+/*
+Require GitHub Actions 'repository' claim.
+e.g.) RequireGithubRepository("octo-org/octo-repo")
+*/
+func RequireGithubRepository(patterns ...string) Policy {
+	return requireClaimMatches("repository", patterns)
+}
+
+/*
+Require GitHub Actions 'ref' claim.
+e.g.) RequireGithubRef("refs/heads/main", "refs/tags/v*")
+*/
+func RequireGithubRef(patterns ...string) Policy {
+	return requireClaimMatches("ref", patterns)
+}
+
+/*
+Require GitHub Actions 'environment' claim.
+e.g.) RequireGithubEnvironment("production")
+*/
+func RequireGithubEnvironment(patterns ...string) Policy {
+	return requireClaimMatches("environment", patterns)
+}
+
+/*
+Require GitHub Actions 'job_workflow_ref' claim, for reusable workflow.
+e.g.) RequireGithubJobWorkflowRef("octo-org/octo-automation/.github/workflows/deploy.yml@refs/heads/main")
+*/
+func RequireGithubJobWorkflowRef(patterns ...string) Policy {
+	return requireClaimMatches("job_workflow_ref", patterns)
+}
@@ -0,0 +1,31 @@
+package secure_backend
+
+import (
+	"context"
+	"log/slog"
+)
+
+/*
+GitHub Actions OpenID Connect issuer.
+see) https://docs.github.com/en/actions/deployment/security-hardening-your-deployments/about-security-hardening-with-openid-connect
+*/
+const GithubActionsIssuerUrl = "https://token.actions.githubusercontent.com"
+
+/*
+Verifier for GitHub Actions OIDC token.
+for CI-to-backend calls, instead of long-lived API Key.
+
+Token is requested in workflow by 'id-token: write' permission, with your audience.
+Verified token should be authorized by policy,
+e.g.) RequireGithubRepository(), RequireGithubEnvironment()
+*/
+type GithubActionsVerifier interface {
+	// Set custom logger.
+	SetLogger(logger *Logger)
+
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
+	// Verify token.
+	Verify(ctx context.Context, token string) (*VerifiedGithubActionsToken, error)
+}
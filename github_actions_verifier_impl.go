@@ -0,0 +1,47 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+type githubActionsVerifierImpl struct {
+	oidc *oidcVerifierImpl
+}
+
+func (it *securityContextImpl) NewGithubActionsVerifier(ctx context.Context, audience string) (GithubActionsVerifier, error) {
+	return it.newGithubActionsVerifier(ctx, GithubActionsIssuerUrl, audience)
+}
+
+func (it *securityContextImpl) newGithubActionsVerifier(ctx context.Context, issuerUrl string, audience string) (GithubActionsVerifier, error) {
+	if len(audience) == 0 {
+		return nil, errors.New("GitHub Actions audience is empty")
+	}
+	oidc, err := it.newOidcVerifier(ctx, &OidcVerifierConfigs{
+		IssuerUrl: issuerUrl,
+		Audiences: []string{audience},
+	}, metricsVerifierGithubActions)
+	if err != nil {
+		return nil, err
+	}
+	return &githubActionsVerifierImpl{
+		oidc: oidc,
+	}, nil
+}
+
+func (it *githubActionsVerifierImpl) SetLogger(logger *Logger) {
+	it.oidc.SetLogger(logger)
+}
+
+func (it *githubActionsVerifierImpl) SetSlogLogger(logger *slog.Logger) {
+	it.oidc.SetSlogLogger(logger)
+}
+
+func (it *githubActionsVerifierImpl) Verify(ctx context.Context, token string) (*VerifiedGithubActionsToken, error) {
+	verified, err := it.oidc.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return newVerifiedGithubActionsToken(verified), nil
+}
@@ -0,0 +1,90 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eaglesakura/go-secure-backend/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubActionsVerifierImpl_Verify(t *testing.T) {
+	ctx := context.Background()
+	issuer := testutils.NewFakeOidcIssuer()
+	defer issuer.Close()
+
+	owner := newSecurityContextForTest()
+	verifier, err := owner.newGithubActionsVerifier(ctx, issuer.Url(), "https://api.example.com")
+	assert.NoError(t, err)
+
+	sign := func(audience string, environment string) string {
+		return issuer.Sign(map[string]interface{}{
+			"iss":              issuer.Url(),
+			"aud":              audience,
+			"sub":              "repo:octo-org/octo-repo:environment:" + environment,
+			"exp":              time.Now().Add(5 * time.Minute).Unix(),
+			"repository":       "octo-org/octo-repo",
+			"repository_owner": "octo-org",
+			"ref":              "refs/heads/main",
+			"environment":      environment,
+			"job_workflow_ref": "octo-org/octo-automation/.github/workflows/deploy.yml@refs/heads/main",
+			"actor":            "octocat",
+		})
+	}
+
+	verified, err := verifier.Verify(ctx, sign("https://api.example.com", "production"))
+	assert.NoError(t, err)
+	assert.Equal(t, "octo-org/octo-repo", verified.Repository)
+	assert.Equal(t, "octo-org", verified.RepositoryOwner)
+	assert.Equal(t, "refs/heads/main", verified.Ref)
+	assert.Equal(t, "production", verified.Environment)
+	assert.Equal(t, "octocat", verified.Actor)
+	assert.Equal(t, "repo:octo-org/octo-repo:environment:production", verified.GetUserId())
+
+	_, err = verifier.Verify(ctx, sign("https://other.example.com", "production"))
+	assert.True(t, errors.Is(err, ErrInvalidAudience))
+
+	policy := AllOf(
+		RequireGithubRepository("octo-org/*"),
+		RequireGithubRef("refs/heads/main", "refs/tags/v*"),
+		RequireGithubEnvironment("production"),
+		RequireGithubJobWorkflowRef("octo-org/octo-automation/.github/workflows/deploy.yml@refs/heads/main"),
+	)
+	assert.NoError(t, Authorize(ctx, policy, verified))
+
+	staging, err := verifier.Verify(ctx, sign("https://api.example.com", "staging"))
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(Authorize(ctx, policy, staging), ErrPermissionDenied))
+	assert.True(t, errors.Is(Authorize(ctx, RequireGithubRepository("other-org/octo-repo"), staging), ErrPermissionDenied))
+
+	// middleware
+	handler := NewGithubActionsMiddleware(verifier, policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "octo-org/octo-repo", VerifiedGithubActionsTokenFromContext(r.Context()).Repository)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for token, status := range map[string]int{
+		sign("https://api.example.com", "production"): http.StatusNoContent,
+		sign("https://api.example.com", "staging"):    http.StatusForbidden,
+		"broken": http.StatusUnauthorized,
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/admin/deploy", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		assert.Equal(t, status, w.Code)
+	}
+
+	// every repository would be allowed without policy, so denied.
+	handler = NewGithubActionsMiddleware(verifier, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	request := httptest.NewRequest(http.MethodPost, "/admin/deploy", nil)
+	request.Header.Set("Authorization", "Bearer "+sign("https://api.example.com", "production"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
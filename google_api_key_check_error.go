@@ -0,0 +1,156 @@
+package secure_backend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+'Service Control' check error codes.
+see) https://cloud.google.com/service-infrastructure/docs/service-control/reference/rest/v1/services/check#Code
+*/
+const (
+	GoogleApiKeyErrorApiKeyInvalid        = "API_KEY_INVALID"
+	GoogleApiKeyErrorApiKeyExpired        = "API_KEY_EXPIRED"
+	GoogleApiKeyErrorApiKeyNotFound       = "API_KEY_NOT_FOUND"
+	GoogleApiKeyErrorIpAddressBlocked     = "IP_ADDRESS_BLOCKED"
+	GoogleApiKeyErrorRefererBlocked       = "REFERER_BLOCKED"
+	GoogleApiKeyErrorClientAppBlocked     = "CLIENT_APP_BLOCKED"
+	GoogleApiKeyErrorApiTargetBlocked     = "API_TARGET_BLOCKED"
+	GoogleApiKeyErrorPermissionDenied     = "PERMISSION_DENIED"
+	GoogleApiKeyErrorResourceExhausted    = "RESOURCE_EXHAUSTED"
+	GoogleApiKeyErrorServiceNotActivated  = "SERVICE_NOT_ACTIVATED"
+	GoogleApiKeyErrorBillingDisabled      = "BILLING_DISABLED"
+	GoogleApiKeyErrorProjectDeleted       = "PROJECT_DELETED"
+	GoogleApiKeyErrorProjectInvalid       = "PROJECT_INVALID"
+	GoogleApiKeyErrorConsumerInvalid      = "CONSUMER_INVALID"
+	GoogleApiKeyErrorNamespaceUnavailable = "NAMESPACE_LOOKUP_UNAVAILABLE"
+	GoogleApiKeyErrorServiceUnavailable   = "SERVICE_STATUS_UNAVAILABLE"
+	GoogleApiKeyErrorBillingUnavailable   = "BILLING_STATUS_UNAVAILABLE"
+	GoogleApiKeyErrorQuotaUnavailable     = "QUOTA_CHECK_UNAVAILABLE"
+)
+
+/*
+One of 'Service Control' check error.
+*/
+type GoogleApiKeyCheckErrorDetail struct {
+	/*
+		Error code.
+		e.g.) "API_KEY_INVALID"
+	*/
+	Code string
+
+	/*
+		Free-form text with error details.
+	*/
+	Detail string
+
+	/*
+		Subject of error.
+		e.g.) "projects/123456"
+	*/
+	Subject string
+}
+
+/*
+API Key rejected by 'Service Control' check API.
+
+Use errors.As() to get this error.
+
+	var checkErr *GoogleApiKeyCheckError
+	if errors.As(err, &checkErr) && checkErr.IsConfigurationError() {
+		// alert!
+	}
+*/
+type GoogleApiKeyCheckError struct {
+	/*
+		'Service Control' service name.
+	*/
+	ServiceName string
+
+	/*
+		All check errors.
+	*/
+	Errors []*GoogleApiKeyCheckErrorDetail
+}
+
+func (it *GoogleApiKeyCheckError) Error() string {
+	codes := make([]string, 0, len(it.Errors))
+	for _, e := range it.Errors {
+		codes = append(codes, fmt.Sprintf("%v(%v)", e.Code, e.Detail))
+	}
+	return fmt.Sprintf("API Validation error[%v]: %v", it.ServiceName, strings.Join(codes, ","))
+}
+
+// Returns true if target is ErrBackendUnavailable(backend unavailable) or ErrInvalidApiKey(otherwise).
+func (it *GoogleApiKeyCheckError) Is(target error) bool {
+	if it.IsUnavailable() {
+		return target == ErrBackendUnavailable
+	}
+	return target == ErrInvalidApiKey
+}
+
+// Returns true if 'Service Control' backend unavailable, caller can retry.
+func (it *GoogleApiKeyCheckError) Retryable() bool {
+	return it.IsUnavailable()
+}
+
+// Returns true if this error has the error code.
+func (it *GoogleApiKeyCheckError) HasCode(code string) bool {
+	for _, e := range it.Errors {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if error caused by GCP project configuration(not a client).
+// e.g.) Service not activated, billing disabled.
+func (it *GoogleApiKeyCheckError) IsConfigurationError() bool {
+	for _, e := range it.Errors {
+		switch e.Code {
+		case GoogleApiKeyErrorServiceNotActivated,
+			GoogleApiKeyErrorBillingDisabled,
+			GoogleApiKeyErrorProjectDeleted,
+			GoogleApiKeyErrorProjectInvalid:
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if error caused by 'Service Control' backend.
+func (it *GoogleApiKeyCheckError) IsUnavailable() bool {
+	for _, e := range it.Errors {
+		switch e.Code {
+		case GoogleApiKeyErrorNamespaceUnavailable,
+			GoogleApiKeyErrorServiceUnavailable,
+			GoogleApiKeyErrorBillingUnavailable,
+			GoogleApiKeyErrorQuotaUnavailable:
+			return true
+		}
+	}
+	return false
+}
+
+// Returns HTTP status code for this error.
+//
+//   - 503: 'Service Control' backend unavailable.
+//   - 401: API Key is invalid, expired or not found.
+//   - 429: Quota exceeded.
+//   - 403: Otherwise.
+func (it *GoogleApiKeyCheckError) HttpStatusCode() int {
+	if it.IsUnavailable() {
+		return http.StatusServiceUnavailable
+	} else if it.HasCode(GoogleApiKeyErrorApiKeyInvalid) ||
+		it.HasCode(GoogleApiKeyErrorApiKeyExpired) ||
+		it.HasCode(GoogleApiKeyErrorApiKeyNotFound) {
+		return http.StatusUnauthorized
+	} else if it.HasCode(GoogleApiKeyErrorResourceExhausted) {
+		return http.StatusTooManyRequests
+	} else {
+		return http.StatusForbidden
+	}
+}
@@ -0,0 +1,42 @@
+package secure_backend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoogleApiKeyCheckError(t *testing.T) {
+	var err error = &GoogleApiKeyCheckError{
+		ServiceName: "example.appspot.com",
+		Errors: []*GoogleApiKeyCheckErrorDetail{
+			{Code: GoogleApiKeyErrorApiKeyInvalid, Detail: "invalid"},
+		},
+	}
+	err = fmt.Errorf("wrapped: %w", err)
+
+	var checkErr *GoogleApiKeyCheckError
+	assert.True(t, errors.As(err, &checkErr))
+	assert.True(t, checkErr.HasCode(GoogleApiKeyErrorApiKeyInvalid))
+	assert.False(t, checkErr.IsConfigurationError())
+	assert.False(t, checkErr.IsUnavailable())
+	assert.Equal(t, http.StatusUnauthorized, checkErr.HttpStatusCode())
+}
+
+func TestGoogleApiKeyCheckError_HttpStatusCode(t *testing.T) {
+	newErr := func(code string) *GoogleApiKeyCheckError {
+		return &GoogleApiKeyCheckError{
+			Errors: []*GoogleApiKeyCheckErrorDetail{{Code: code}},
+		}
+	}
+
+	assert.Equal(t, http.StatusForbidden, newErr(GoogleApiKeyErrorServiceNotActivated).HttpStatusCode())
+	assert.True(t, newErr(GoogleApiKeyErrorServiceNotActivated).IsConfigurationError())
+	assert.True(t, newErr(GoogleApiKeyErrorBillingDisabled).IsConfigurationError())
+	assert.Equal(t, http.StatusForbidden, newErr(GoogleApiKeyErrorRefererBlocked).HttpStatusCode())
+	assert.Equal(t, http.StatusTooManyRequests, newErr(GoogleApiKeyErrorResourceExhausted).HttpStatusCode())
+	assert.Equal(t, http.StatusServiceUnavailable, newErr(GoogleApiKeyErrorServiceUnavailable).HttpStatusCode())
+}
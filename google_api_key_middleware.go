@@ -0,0 +1,43 @@
+package secure_backend
+
+import (
+	"errors"
+	"net/http"
+)
+
+/*
+HTTP middleware for Google API Key.
+
+API Key and client context are read from request by NewGoogleApiKeyVerifyRequest().
+If API Key is rejected, then response status from GoogleApiKeyCheckError.HttpStatusCode().
+If ServiceControl API call failed, then response '503 Service Unavailable'.
+Verified API Key is set to request context, see VerifiedGoogleApiKeyFromContext().
+
+e.g.)
+
+	http.Handle("/api/", NewGoogleApiKeyMiddleware(verifier)(apiHandler))
+*/
+func NewGoogleApiKeyMiddleware(verifier GoogleApiKeyVerifier) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := NewGoogleApiKeyVerifyRequest(r)
+			if len(request.ApiKey) == 0 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			verified, err := verifier.VerifyRequest(r.Context(), request)
+			if err != nil {
+				status := http.StatusServiceUnavailable
+				var checkErr *GoogleApiKeyCheckError
+				if errors.As(err, &checkErr) {
+					status = checkErr.HttpStatusCode()
+				}
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithVerifiedGoogleApiKey(r.Context(), verified)))
+		})
+	}
+}
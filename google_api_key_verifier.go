@@ -1,6 +1,9 @@
 package secure_backend
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 /*
 Google Cloud Platform API Key verify.
@@ -9,11 +12,21 @@ type GoogleApiKeyVerifier interface {
 	// Set custom logger.
 	SetLogger(logger *Logger)
 
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
 	// Set custom service name for 'Service Control' check API.
 	// Default is 'your-gcp-name.appspot.com'
 	// https://cloud.google.com/service-infrastructure/docs/service-control/getting-started?hl=en
 	SetServiceName(serviceName string)
 
 	// Verify your API Key.
-	Verify(ctx context.Context, apiKey string) error
+	// If API Key rejected by ServiceControl API, then returns *GoogleApiKeyCheckError(errors.Is(err, ErrInvalidApiKey)).
+	// If ServiceControl API call failed, then returns error that matches ErrBackendUnavailable.
+	Verify(ctx context.Context, apiKey string) (*VerifiedGoogleApiKey, error)
+
+	// Verify your API Key with client context.
+	// Use this method when API Key restricted by IP address, HTTP referrer, Android app or iOS app.
+	// see) https://cloud.google.com/docs/authentication/api-keys?hl=en#api_key_restrictions
+	VerifyRequest(ctx context.Context, request *GoogleApiKeyVerifyRequest) (*VerifiedGoogleApiKey, error)
 }
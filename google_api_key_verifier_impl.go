@@ -3,16 +3,18 @@ package secure_backend
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/servicecontrol/v1"
 )
 
 type googleApiKeyVerifierImpl struct {
 	owner *securityContextImpl
 
-	logger *Logger
+	logger *slog.Logger
 
 	/*
 		Custom service name.
@@ -20,15 +22,11 @@ type googleApiKeyVerifierImpl struct {
 	serviceName string
 }
 
-func (it *googleApiKeyVerifierImpl) logInfo(msg string) {
-	it.logger.logInfo(msg)
-}
-
-func (it *googleApiKeyVerifierImpl) logError(msg string) {
-	it.logger.logError(msg)
+func (it *googleApiKeyVerifierImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
 }
 
-func (it *googleApiKeyVerifierImpl) SetLogger(logger *Logger) {
+func (it *googleApiKeyVerifierImpl) SetSlogLogger(logger *slog.Logger) {
 	it.logger = logger
 }
 
@@ -36,7 +34,13 @@ func (it *googleApiKeyVerifierImpl) SetServiceName(serviceName string) {
 	it.serviceName = serviceName
 }
 
-func (it *googleApiKeyVerifierImpl) verifyImpl(ctx context.Context, key *validGoogleApiKey) error {
+func (it *googleApiKeyVerifierImpl) verifyImpl(ctx context.Context, key *validGoogleApiKey) (result *VerifiedGoogleApiKey, err error) {
+	ctx, span := it.owner.startSpan(ctx, "GoogleApiKeyVerifier.verifyImpl",
+		attribute.String("service", key.serviceName))
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	operationId := uuid.New().String()
 	client := it.owner.gcp.serviceControlClient
 	resp, err := client.Services.Check(key.serviceName, &servicecontrol.CheckRequest{
@@ -45,51 +49,129 @@ func (it *googleApiKeyVerifierImpl) verifyImpl(ctx context.Context, key *validGo
 			OperationName: "check:" + operationId,
 			ConsumerId:    "api_key:" + key.apiKey,
 			StartTime:     time.Now().Format(time.RFC3339Nano),
+			Labels:        key.operationLabels(),
 		},
 	}).Context(ctx).Do()
 
 	if err != nil {
-		return fmt.Errorf("ServiceControl API call failed: %w", err)
+		err = newVerificationError(ErrBackendUnavailable, "ServiceControl API call failed", err)
+		it.owner.serviceControlStatus.record(err)
+		return nil, err
 	}
+	it.owner.serviceControlStatus.record(nil)
 
 	if len(resp.CheckErrors) != 0 {
-		var message string
-		for i, e := range resp.CheckErrors {
-			it.logInfo(fmt.Sprintf("API Key validation error[%v]: %v", i, e.Detail))
-			message += fmt.Sprintf("%v,", e.Detail)
+		checkErr := &GoogleApiKeyCheckError{
+			ServiceName: key.serviceName,
+		}
+		for _, e := range resp.CheckErrors {
+			checkErr.Errors = append(checkErr.Errors, &GoogleApiKeyCheckErrorDetail{
+				Code:    e.Code,
+				Detail:  e.Detail,
+				Subject: e.Subject,
+			})
 		}
 
-		return fmt.Errorf("API Validation error[%v]", message)
+		return nil, checkErr
 	}
 
-	return nil
+	result = &VerifiedGoogleApiKey{
+		ServiceName: key.serviceName,
+	}
+	if resp.CheckInfo != nil && resp.CheckInfo.ConsumerInfo != nil {
+		result.ConsumerProjectNumber = resp.CheckInfo.ConsumerInfo.ProjectNumber
+	}
+	return result, nil
 }
 
-func (it *googleApiKeyVerifierImpl) Verify(ctx context.Context, apiKey string) error {
+func (it *googleApiKeyVerifierImpl) Verify(ctx context.Context, apiKey string) (*VerifiedGoogleApiKey, error) {
+	return it.VerifyRequest(ctx, &GoogleApiKeyVerifyRequest{
+		ApiKey: apiKey,
+	})
+}
+
+func (it *googleApiKeyVerifierImpl) VerifyRequest(ctx context.Context, request *GoogleApiKeyVerifyRequest) (result *VerifiedGoogleApiKey, err error) {
+	startAt := time.Now()
+	path := "service_control"
+	ctx, span := it.owner.startSpan(ctx, "GoogleApiKeyVerifier.Verify")
+	defer func() {
+		it.owner.getMetrics().ObserveVerification(metricsVerifierGoogleApiKey, getVerificationResult(err), time.Since(startAt))
+		endSpan(span, err)
+
+		it.owner.recentErrors.add(metricsVerifierGoogleApiKey, err)
+
+		outcome, reasonCode := getAuditOutcome(err)
+		it.owner.emitAudit(ctx, &AuditEvent{
+			Principal:  it.owner.redaction.secret(request.ApiKey),
+			Method:     path,
+			Verifier:   metricsVerifierGoogleApiKey,
+			Outcome:    outcome,
+			ReasonCode: reasonCode,
+			ClientIp:   request.ClientIp,
+		})
+	}()
+
 	// check cache
 	validApiKeys := it.owner.gcp.validApiKeys
 
 	key := validGoogleApiKey{
-		apiKey:      apiKey,
-		serviceName: it.serviceName,
+		apiKey:         request.ApiKey,
+		serviceName:    it.serviceName,
+		clientIp:       request.ClientIp,
+		referer:        getNormalizedReferer(request.Referer),
+		androidPackage: request.AndroidPackage,
+		androidCert:    request.AndroidCert,
+		iosBundleId:    request.IosBundleId,
 	}
 
 	if len(key.serviceName) == 0 {
 		key.serviceName = fmt.Sprintf("%v.appspot.com", it.owner.gcp.projectId)
 	}
 
-	if _, ok := validApiKeys.Get(key.cacheKey()); !ok {
+	logger := it.logger.With(
+		slog.String("service", key.serviceName),
+		it.owner.redaction.apiKeyAttr(key.apiKey),
+	)
+
+	span.SetAttributes(attribute.String("service", key.serviceName))
+	cached, cacheHit := validApiKeys.Get(key.cacheKey())
+	it.owner.getMetrics().ObserveApiKeyCache(cacheHit)
+	if !cacheHit {
+		span.SetAttributes(
+			attribute.Bool("cache_hit", false),
+			attribute.String("path", "service_control"),
+		)
 		// cache not found.
 		// do check this API Key.
-		it.logInfo(fmt.Sprintf("Validation API Key by ServiceControl API: %v:hash(%v)", key.serviceName, sha512sum(key.apiKey)))
-		if err := it.verifyImpl(ctx, &key); err != nil {
-			return err
+		logger.Debug("Validation API Key by ServiceControl API")
+		verified, err := it.verifyImpl(ctx, &key)
+		if err != nil {
+			logger.Log(ctx, getVerificationErrorLogLevel(err), "API Key verify failed",
+				slog.String("path", "service_control"),
+				slog.Duration("duration", time.Since(startAt)),
+				slog.String("outcome", "denied"),
+				slog.Any("error", err))
+			return nil, err
 		}
+		logger.Debug("API Key verified",
+			slog.String("path", "service_control"),
+			slog.Duration("duration", time.Since(startAt)),
+			slog.String("outcome", "allowed"))
+		result = verified
 	} else {
-		it.logInfo(fmt.Sprintf("Valid API Key from cache: %v", apiKey))
+		path = "cache"
+		span.SetAttributes(
+			attribute.Bool("cache_hit", true),
+			attribute.String("path", "cache"),
+		)
+		logger.Debug("API Key verified",
+			slog.String("path", "cache"),
+			slog.Duration("duration", time.Since(startAt)),
+			slog.String("outcome", "allowed"))
+		result = cached.(*VerifiedGoogleApiKey)
 	}
 
 	// valid API Key.
-	validApiKeys.Set(key.cacheKey(), &key, time.Hour)
-	return nil
+	validApiKeys.Set(key.cacheKey(), result, time.Hour)
+	return result, nil
 }
@@ -2,6 +2,8 @@ package secure_backend
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/eaglesakura/go-secure-backend/testutils"
@@ -14,9 +16,14 @@ func TestGoogleApiKeyVerifierImpl_Verify(t *testing.T) {
 	assert.NoError(t, owner.init(ctx))
 	verifier := owner.NewGoogleApiKeyVerifier()
 
-	assert.NoError(t, verifier.Verify(ctx, testutils.GetGoogleApiKeyForTest()))
+	verified, err := verifier.Verify(ctx, testutils.GetGoogleApiKeyForTest())
+	assert.NoError(t, err)
+	assert.NotNil(t, verified)
+	assert.NotEmpty(t, verified.ServiceName)
 	// from cache
-	assert.NoError(t, verifier.Verify(ctx, testutils.GetGoogleApiKeyForTest()))
+	cached, err := verifier.Verify(ctx, testutils.GetGoogleApiKeyForTest())
+	assert.NoError(t, err)
+	assert.Equal(t, verified, cached)
 }
 
 func TestGoogleApiKeyVerifierImpl_Verify_invalid(t *testing.T) {
@@ -25,5 +32,11 @@ func TestGoogleApiKeyVerifierImpl_Verify_invalid(t *testing.T) {
 	assert.NoError(t, owner.init(ctx))
 	verifier := owner.NewGoogleApiKeyVerifier()
 
-	assert.Error(t, verifier.Verify(ctx, "this is invalid key"))
+	verified, err := verifier.Verify(ctx, "this is invalid key")
+	assert.Error(t, err)
+	assert.Nil(t, verified)
+
+	var checkErr *GoogleApiKeyCheckError
+	assert.True(t, errors.As(err, &checkErr))
+	assert.Equal(t, http.StatusUnauthorized, checkErr.HttpStatusCode())
 }
@@ -0,0 +1,78 @@
+package secure_backend
+
+import (
+	"net/http"
+)
+
+/*
+API Key with client context.
+Empty value is not sent to 'Service Control' check API.
+*/
+type GoogleApiKeyVerifyRequest struct {
+	/*
+		Google API Key.
+	*/
+	ApiKey string
+
+	/*
+		Caller IP address.
+		for 'IP addresses' restriction.
+	*/
+	ClientIp string
+
+	/*
+		HTTP Referer header.
+		for 'HTTP referrers' restriction.
+		Fragment is removed, path and query are checked.
+	*/
+	Referer string
+
+	/*
+		'X-Android-Package' header.
+		for 'Android apps' restriction.
+	*/
+	AndroidPackage string
+
+	/*
+		'X-Android-Cert' header, SHA-1 signing certificate fingerprint.
+		for 'Android apps' restriction.
+	*/
+	AndroidCert string
+
+	/*
+		'X-Ios-Bundle-Identifier' header.
+		for 'iOS apps' restriction.
+	*/
+	IosBundleId string
+}
+
+/*
+Build verify request from HTTP request.
+
+API Key is read from 'X-Goog-Api-Key' header or 'key' query parameter.
+Caller IP address is read from context(see NewClientIpMiddleware()) or remote address.
+'X-Forwarded-For' header is not trusted by default.
+*/
+func NewGoogleApiKeyVerifyRequest(r *http.Request) *GoogleApiKeyVerifyRequest {
+	apiKey := r.Header.Get("X-Goog-Api-Key")
+	if len(apiKey) == 0 {
+		apiKey = r.URL.Query().Get("key")
+	}
+
+	return &GoogleApiKeyVerifyRequest{
+		ApiKey:         apiKey,
+		ClientIp:       getHttpClientIp(r),
+		Referer:        r.Referer(),
+		AndroidPackage: r.Header.Get("X-Android-Package"),
+		AndroidCert:    r.Header.Get("X-Android-Cert"),
+		IosBundleId:    r.Header.Get("X-Ios-Bundle-Identifier"),
+	}
+}
+
+func getHttpClientIp(r *http.Request) string {
+	if clientIp := ClientIpFromContext(r.Context()); len(clientIp) > 0 {
+		return clientIp
+	}
+	var proxies *TrustedProxies
+	return proxies.ClientIp(r)
+}
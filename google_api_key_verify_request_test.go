@@ -0,0 +1,70 @@
+package secure_backend
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGoogleApiKeyVerifyRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "https://example.com/api?key=query-key", nil)
+	r.RemoteAddr = "192.0.2.1:12345"
+	r.Header.Set("Referer", "https://example.com/index.html")
+	r.Header.Set("X-Android-Package", "com.example.app")
+	r.Header.Set("X-Android-Cert", "0123456789ABCDEF")
+	r.Header.Set("X-Ios-Bundle-Identifier", "com.example.ios")
+
+	request := NewGoogleApiKeyVerifyRequest(r)
+	assert.Equal(t, "query-key", request.ApiKey)
+	assert.Equal(t, "192.0.2.1", request.ClientIp)
+	assert.Equal(t, "https://example.com/index.html", request.Referer)
+	assert.Equal(t, "com.example.app", request.AndroidPackage)
+	assert.Equal(t, "0123456789ABCDEF", request.AndroidCert)
+	assert.Equal(t, "com.example.ios", request.IosBundleId)
+}
+
+func TestNewGoogleApiKeyVerifyRequest_header(t *testing.T) {
+	r := httptest.NewRequest("GET", "https://example.com/api?key=query-key", nil)
+	r.Header.Set("X-Goog-Api-Key", "header-key")
+	r.RemoteAddr = "192.0.2.1:12345"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 10.0.0.1")
+
+	// 'X-Forwarded-For' is not trusted.
+	request := NewGoogleApiKeyVerifyRequest(r)
+	assert.Equal(t, "header-key", request.ApiKey)
+	assert.Equal(t, "192.0.2.1", request.ClientIp)
+
+	// by trusted proxies.
+	proxies, err := NewTrustedProxies("192.0.2.1", "10.0.0.0/8")
+	assert.NoError(t, err)
+	request = NewGoogleApiKeyVerifyRequest(r.WithContext(WithClientIp(r.Context(), proxies.ClientIp(r))))
+	assert.Equal(t, "198.51.100.1", request.ClientIp)
+}
+
+func Test_validGoogleApiKey_operationLabels(t *testing.T) {
+	key := &validGoogleApiKey{
+		apiKey:      "key",
+		serviceName: "example.appspot.com",
+		clientIp:    "192.0.2.1",
+	}
+	labels := key.operationLabels()
+	assert.Equal(t, map[string]string{
+		"servicecontrol.googleapis.com/caller_ip": "192.0.2.1",
+	}, labels)
+}
+
+func Test_validGoogleApiKey_cacheKey(t *testing.T) {
+	a := &validGoogleApiKey{apiKey: "key", serviceName: "example.appspot.com", referer: "a:b", androidPackage: "c"}
+	b := &validGoogleApiKey{apiKey: "key", serviceName: "example.appspot.com", referer: "a", androidPackage: "b:c"}
+	assert.NotEqual(t, a.cacheKey(), b.cacheKey())
+	assert.Equal(t, a.cacheKey(), (&validGoogleApiKey{apiKey: "key", serviceName: "example.appspot.com", referer: "a:b", androidPackage: "c"}).cacheKey())
+	assert.Equal(t, 64, len(a.cacheKey()))
+}
+
+func Test_getNormalizedReferer(t *testing.T) {
+	assert.Equal(t, "https://example.com/app/index.html?q=1", getNormalizedReferer("https://example.com/app/index.html?q=1#top"))
+	assert.Equal(t, "http://example.com:8080", getNormalizedReferer("http://example.com:8080"))
+	assert.Equal(t, "", getNormalizedReferer(""))
+	assert.Equal(t, "", getNormalizedReferer("example.com/index.html"))
+}
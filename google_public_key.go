@@ -1,6 +1,8 @@
 package secure_backend
 
 import (
+	"context"
+	"crypto"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
@@ -9,15 +11,47 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
+/*
+HTTP client for public key download(Google public keys, OpenID configuration and JWKS).
+*/
+var publicKeyHttpClient = &http.Client{Timeout: 10 * time.Second}
+
+/*
+Max response size of public key download.
+*/
+const maxPublicKeyResponseSize = 1 << 20
+
+/*
+Returns response body, up to maxPublicKeyResponseSize.
+*/
+func readPublicKeyResponse(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPublicKeyResponseSize+1))
+	if err != nil {
+		return nil, err
+	} else if len(body) > maxPublicKeyResponseSize {
+		return nil, fmt.Errorf("response is too large(> %v bytes)", maxPublicKeyResponseSize)
+	}
+	return body, nil
+}
+
+/*
+Public key for JWT verification.
+publicKey is *rsa.PublicKey or *ecdsa.PublicKey.
+*/
 type googlePublicKey struct {
 	kid       string
-	publicKey *rsa.PublicKey
+	publicKey crypto.PublicKey
 }
 
-func getGooglePublicKeys(url string) ([]*googlePublicKey, error) {
-	resp, err := http.Get(url)
+func getGooglePublicKeys(ctx context.Context, url string) ([]*googlePublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Google public key request failed / %v: %w", url, err)
+	}
+	resp, err := publicKeyHttpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("Google public key download failed / %v: %w", url, err)
 	} else if resp.Body != nil {
@@ -30,7 +64,10 @@ func getGooglePublicKeys(url string) ([]*googlePublicKey, error) {
 		return nil, fmt.Errorf("Google public key download status error: %v / %v", resp.StatusCode, url)
 	}
 
-	metadataBody, err := io.ReadAll(resp.Body)
+	metadataBody, err := readPublicKeyResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("Google public key read failed / %v: %w", url, err)
+	}
 	keys := map[string]string{}
 
 	err = json.Unmarshal(metadataBody, &keys)
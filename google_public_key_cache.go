@@ -1,107 +1,351 @@
 package secure_backend
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+/*
+Min interval of key refresh by unknown key.
+Unknown key between refreshes is rejected, without download.
+*/
+const minPublicKeyRefreshInterval = time.Minute
+
 type googlePublicKeyCache struct {
-	logger *Logger
+	logger  *slog.Logger
+	tracer  trace.Tracer
+	metrics Metrics
 	/*
 		Metadata server URL.
 	*/
 	metadataUrl string
+
+	/*
+		Public key downloader.
+		e.g.) getGooglePublicKeys(), getJwksPublicKeys()
+	*/
+	fetchKeys   func(ctx context.Context, url string) ([]*googlePublicKey, error)
 	lock        *sync.Mutex
 	latestKey   *googlePublicKey
 	offlineKeys map[string]*googlePublicKey
 	allKeys     map[string]*googlePublicKey
+
+	/*
+		Time validation policy for parsed token.
+	*/
+	tokenTime *tokenTimePolicy
+
+	/*
+		Last refresh result.
+	*/
+	refreshedAt      time.Time
+	lastRefreshError error
+
+	/*
+		Last refresh started, for rate limit of refresh by unknown key.
+	*/
+	refreshStartedAt   time.Time
+	minRefreshInterval time.Duration
+
+	/*
+		Background refresher.
+	*/
+	cancelRefresher context.CancelFunc
+	refresherDone   chan struct{}
 }
 
 func (it *googlePublicKeyCache) addOfflineKey(key *googlePublicKey) {
+	it.lock.Lock()
+	defer it.lock.Unlock()
 	it.offlineKeys[key.kid] = key
 	if it.latestKey == nil {
 		it.latestKey = key
 	}
 }
 
-func (it *googlePublicKeyCache) refreshKeys() error {
+/*
+Returns snapshot of latest key and all keys.
+allKeys map is replaced on refresh, never modified.
+*/
+func (it *googlePublicKeyCache) keys() (latest *googlePublicKey, all map[string]*googlePublicKey) {
 	it.lock.Lock()
 	defer it.lock.Unlock()
+	return it.latestKey, it.allKeys
+}
 
-	keys, err := getGooglePublicKeys(it.metadataUrl)
+func (it *googlePublicKeyCache) setLatestKey(key *googlePublicKey) {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	it.latestKey = key
+}
+
+func (it *googlePublicKeyCache) refreshKeys(ctx context.Context) (err error) {
+	ctx, span := it.tracer.Start(ctx, "googlePublicKeyCache.refreshKeys",
+		trace.WithAttributes(attribute.String("url", it.metadataUrl)))
+	defer func() {
+		it.metrics.ObserveKeyRefresh(it.metadataUrl, err)
+		endSpan(span, err)
+	}()
+
+	startAt := time.Now()
+	it.lock.Lock()
+	it.refreshStartedAt = startAt
+	it.lock.Unlock()
+
+	// download without lock, verification continues by current keys.
+	keys, err := it.fetchKeys(ctx, it.metadataUrl)
+
+	it.lock.Lock()
+	defer it.lock.Unlock()
 	if err != nil {
-		return fmt.Errorf("Public key cache refresh failed: %w", err)
+		it.logger.Error("public key refresh failed",
+			slog.String("url", it.metadataUrl),
+			slog.Duration("duration", time.Since(startAt)),
+			slog.Any("error", err))
+		it.lastRefreshError = newVerificationError(ErrBackendUnavailable, "Public key cache refresh failed", err)
+		return it.lastRefreshError
 	}
+	it.lastRefreshError = nil
 
-	it.allKeys = make(map[string]*googlePublicKey)
+	allKeys := make(map[string]*googlePublicKey)
 	for _, key := range it.offlineKeys {
-		it.allKeys[key.kid] = key
+		allKeys[key.kid] = key
 	}
 	for _, key := range keys {
-		it.allKeys[key.kid] = key
+		allKeys[key.kid] = key
 	}
+	it.allKeys = allKeys
 
+	it.refreshedAt = time.Now()
+	span.SetAttributes(attribute.Int("keys", len(it.allKeys)))
+	it.metrics.SetLoadedKeys(it.metadataUrl, len(it.allKeys))
+	it.logger.Info("public key refreshed",
+		slog.String("url", it.metadataUrl),
+		slog.Duration("duration", time.Since(startAt)),
+		slog.Int("keys", len(it.allKeys)))
 	return nil
 }
 
-func (it *googlePublicKeyCache) parseJwt(token string) (*googlePublicKey, *jwt.Token, error) {
+/*
+Returns true if refresh by unknown key is allowed, and reserves it.
+Refresh is allowed once per minRefreshInterval.
+*/
+func (it *googlePublicKeyCache) reserveRefresh() bool {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	now := time.Now()
+	if !it.refreshStartedAt.IsZero() && now.Sub(it.refreshStartedAt) < it.minRefreshInterval {
+		return false
+	}
+	it.refreshStartedAt = now
+	return true
+}
+
+/*
+Returns last refresh time, last refresh error, count of keys and background refresher is running.
+*/
+func (it *googlePublicKeyCache) status() (refreshedAt time.Time, lastRefreshError error, keys int, refreshing bool) {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	return it.refreshedAt, it.lastRefreshError, len(it.allKeys), it.cancelRefresher != nil
+}
+
+/*
+Start background refresher.
+Keys are refreshed every interval, until stopRefresh() called.
+*/
+func (it *googlePublicKeyCache) startRefresh(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	it.lock.Lock()
+	it.cancelRefresher = cancel
+	it.refresherDone = done
+	it.lock.Unlock()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// error is logged, and reported by health.
+				_ = it.refreshKeys(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+/*
+Stop background refresher, and wait for it.
+*/
+func (it *googlePublicKeyCache) stopRefresh(ctx context.Context) error {
+	it.lock.Lock()
+	cancel, done := it.cancelRefresher, it.refresherDone
+	it.cancelRefresher = nil
+	it.lock.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Parse JWT by public key.
+Returns 'true' if token signed by this key, then token claims may be invalid(e.g. expired).
+*/
+func parseJwtWithPublicKey(token string, key *googlePublicKey) (*jwt.Token, bool, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	parsed, err := parser.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return key.publicKey, nil
+	})
+	if err == nil {
+		return parsed, true, nil
+	}
+
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) &&
+		validationErr.Errors&(jwt.ValidationErrorMalformed|jwt.ValidationErrorUnverifiable|jwt.ValidationErrorSignatureInvalid) == 0 {
+		return parsed, true, err
+	}
+	return nil, false, err
+}
+
+/*
+Convert jwt-go error to VerificationError.
+*/
+func newJwtVerificationError(err error) error {
+	var validationErr *jwt.ValidationError
+	if !errors.As(err, &validationErr) {
+		return newVerificationError(ErrMalformedToken, "JWT.parse failed", err)
+	}
+
+	switch {
+	case validationErr.Errors&jwt.ValidationErrorMalformed != 0:
+		return newVerificationError(ErrMalformedToken, "JWT.parse failed", err)
+	case validationErr.Errors&(jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorUnverifiable) != 0:
+		return newVerificationError(ErrInvalidSignature, "JWT signature validation failed", err)
+	case validationErr.Errors&jwt.ValidationErrorExpired != 0:
+		return newVerificationError(ErrTokenExpired, "invalid JWT.exp", err)
+	case validationErr.Errors&(jwt.ValidationErrorNotValidYet|jwt.ValidationErrorIssuedAt) != 0:
+		return newVerificationError(ErrTokenNotValidYet, "invalid JWT.nbf or JWT.iat", err)
+	case validationErr.Errors&jwt.ValidationErrorAudience != 0:
+		return newVerificationError(ErrInvalidAudience, "invalid JWT.aud", err)
+	case validationErr.Errors&jwt.ValidationErrorIssuer != 0:
+		return newVerificationError(ErrInvalidIssuer, "invalid JWT.iss", err)
+	default:
+		return newVerificationError(ErrMalformedToken, "invalid JWT claims", err)
+	}
+}
+
+func (it *googlePublicKeyCache) parseJwtWithKeys(token string, keys map[string]*googlePublicKey) (*googlePublicKey, *jwt.Token, error) {
+	for _, key := range keys {
+		parsedToken, matched, err := parseJwtWithPublicKey(token, key)
+		if matched {
+			it.setLatestKey(key)
+			if err != nil {
+				return key, nil, newJwtVerificationError(err)
+			}
+			return key, parsedToken, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (it *googlePublicKeyCache) parseJwt(ctx context.Context, token string) (key *googlePublicKey, parsed *jwt.Token, err error) {
+	ctx, span := it.tracer.Start(ctx, "googlePublicKeyCache.parseJwt")
+	defer func() {
+		if key != nil {
+			span.SetAttributes(attribute.String("kid", key.kid))
+		}
+		endSpan(span, err)
+	}()
+
+	span.SetAttributes(attribute.Bool("cache_hit", true))
+	key, parsed, err = it.parseJwtImpl(ctx, span, token)
+	if err == nil && parsed != nil {
+		if err = it.tokenTime.validate(parsed.Claims.(jwt.MapClaims)); err != nil {
+			return key, nil, err
+		}
+	}
+	return key, parsed, err
+}
+
+func (it *googlePublicKeyCache) parseJwtImpl(ctx context.Context, span trace.Span, token string) (*googlePublicKey, *jwt.Token, error) {
+	unverified, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, nil, newJwtVerificationError(err)
+	}
+
 	// check latest
-	latest := it.latestKey
+	latest, allKeys := it.keys()
 	if latest != nil {
-		if parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			return latest.publicKey, nil
-		}); err == nil {
+		if parsed, matched, err := parseJwtWithPublicKey(token, latest); matched {
+			if err != nil {
+				return latest, nil, newJwtVerificationError(err)
+			}
 			return latest, parsed, nil
 		}
 	}
 
 	// Try local cache.
-	keys := it.allKeys
-	for _, key := range keys {
-		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			return key.publicKey, nil
-		})
-		if err == nil {
-			it.latestKey = key
-			return key, parsedToken, nil
-		}
+	if key, parsed, err := it.parseJwtWithKeys(token, allKeys); key != nil {
+		return key, parsed, err
 	}
 
-	it.logger.logError("public key not found on memory cache. refresh start.")
+	kid, _ := unverified.Header["kid"].(string)
+	span.SetAttributes(attribute.Bool("cache_hit", false))
+	if !it.reserveRefresh() {
+		it.logger.Warn("public key not found on memory cache. refresh is rate limited.", slog.String("kid", kid))
+		return nil, nil, newVerificationError(ErrUnknownKey, fmt.Sprintf("public key not found(%v), refresh is rate limited", kid), nil)
+	}
+	it.logger.Warn("public key not found on memory cache. refresh start.", slog.String("kid", kid))
 
 	// Not found, refresh
-	err := it.refreshKeys()
-	if err != nil {
+	if err := it.refreshKeys(ctx); err != nil {
 		return nil, nil, err
-	} else {
-		keys = it.allKeys
 	}
 
 	// Try new local cache.
-	for _, key := range keys {
-		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			return key.publicKey, nil
-		})
-		if err == nil {
-			it.latestKey = key
-			return key, parsedToken, nil
-		}
+	_, allKeys = it.keys()
+	if key, parsed, err := it.parseJwtWithKeys(token, allKeys); key != nil {
+		return key, parsed, err
 	}
 
-	it.logger.logError("fatal, Public key not found on google repository")
+	it.logger.Error("fatal, Public key not found on google repository", slog.String("kid", kid))
 
 	// Not found public key.
-	return nil, nil, errors.New("signature validation failed in all public keys")
+	if _, ok := allKeys[kid]; len(kid) > 0 && !ok {
+		return nil, nil, newVerificationError(ErrUnknownKey, fmt.Sprintf("public key not found(%v)", kid), nil)
+	}
+	return nil, nil, newVerificationError(ErrInvalidSignature, "signature validation failed in all public keys", nil)
 }
 
-func newGooglePublicKeyCache(metadataUrl string, logger *Logger) *googlePublicKeyCache {
+func newGooglePublicKeyCache(metadataUrl string, logger *slog.Logger, tracer trace.Tracer, metrics Metrics) *googlePublicKeyCache {
 	return &googlePublicKeyCache{
 		metadataUrl: metadataUrl,
+		fetchKeys:   getGooglePublicKeys,
 		logger:      logger,
+		tracer:      tracer,
+		metrics:     metrics,
 		lock:        new(sync.Mutex),
 		offlineKeys: make(map[string]*googlePublicKey),
+
+		minRefreshInterval: minPublicKeyRefreshInterval,
 	}
 }
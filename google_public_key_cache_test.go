@@ -0,0 +1,107 @@
+package secure_backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_googlePublicKeyCache_parseJwt_withRefresher(t *testing.T) {
+	privateKeys := map[string]*rsa.PrivateKey{}
+	var keys []*googlePublicKey
+	for _, kid := range []string{"key-1", "key-2"} {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		privateKeys[kid] = privateKey
+		keys = append(keys, &googlePublicKey{kid: kid, publicKey: &privateKey.PublicKey})
+	}
+
+	keyCache := newGooglePublicKeyCache("http://127.0.0.1:0/unused", newSlogLogger(&Logger{}), newTracer(nil), &noopMetrics{})
+	keyCache.fetchKeys = func(ctx context.Context, url string) ([]*googlePublicKey, error) {
+		return keys, nil
+	}
+	assert.NoError(t, keyCache.refreshKeys(context.Background()))
+	keyCache.startRefresh(time.Millisecond)
+	defer func() {
+		assert.NoError(t, keyCache.stopRefresh(context.Background()))
+	}()
+
+	var tokens []string
+	for kid, privateKey := range privateKeys {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(privateKey)
+		assert.NoError(t, err)
+		tokens = append(tokens, signed)
+	}
+
+	// run with '-race', latest key is switched by each token.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(50 * time.Millisecond)
+			for i := 0; time.Now().Before(deadline); i++ {
+				_, _, err := keyCache.parseJwt(context.Background(), tokens[i%len(tokens)])
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_googlePublicKeyCache_parseJwt_unknownKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	var fetched int32
+	keyCache := newGooglePublicKeyCache("http://127.0.0.1:0/unused", newSlogLogger(&Logger{}), newTracer(nil), &noopMetrics{})
+	keyCache.fetchKeys = func(ctx context.Context, url string) ([]*googlePublicKey, error) {
+		atomic.AddInt32(&fetched, 1)
+		return nil, nil
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user"})
+	token.Header["kid"] = "unknown"
+	signed, err := token.SignedString(privateKey)
+	assert.NoError(t, err)
+
+	// refreshed once per interval.
+	for i := 0; i < 10; i++ {
+		_, _, err = keyCache.parseJwt(context.Background(), signed)
+		assert.True(t, errors.Is(err, ErrUnknownKey), err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetched))
+
+	keyCache.refreshStartedAt = time.Now().Add(-minPublicKeyRefreshInterval)
+	_, _, err = keyCache.parseJwt(context.Background(), signed)
+	assert.True(t, errors.Is(err, ErrUnknownKey), err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetched))
+}
+
+func Test_getJson_tooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[],"padding":"`))
+		_, _ = w.Write(bytes.Repeat([]byte("a"), maxPublicKeyResponseSize))
+		_, _ = w.Write([]byte(`"}`))
+	}))
+	defer server.Close()
+
+	_, err := getJwksPublicKeys(context.Background(), server.URL)
+	assert.ErrorContains(t, err, "too large")
+	_, err = getGooglePublicKeys(context.Background(), server.URL)
+	assert.ErrorContains(t, err, "too large")
+}
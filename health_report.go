@@ -0,0 +1,250 @@
+package secure_backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+Health status.
+*/
+type HealthStatus string
+
+const (
+	HealthOk       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+	HealthUnready  HealthStatus = "unready"
+)
+
+func (it HealthStatus) worse(other HealthStatus) HealthStatus {
+	rank := map[HealthStatus]int{
+		HealthOk:       0,
+		HealthDegraded: 1,
+		HealthUnready:  2,
+	}
+	if rank[other] > rank[it] {
+		return other
+	}
+	return it
+}
+
+/*
+Health thresholds.
+Key age is checked only if background refresher is running, see KeyRefreshInterval.
+Otherwise keys are refreshed on unknown key, so old keys are not stale.
+*/
+type HealthThresholds struct {
+	/*
+		If public keys are not refreshed in this duration, then degraded.
+		default) 2 hours
+	*/
+	DegradedKeyAge time.Duration
+
+	/*
+		If public keys are not refreshed in this duration, then unready.
+		default) 24 hours
+	*/
+	UnreadyKeyAge time.Duration
+}
+
+/*
+Health of a component.
+*/
+type HealthCheck struct {
+	/*
+		Component name.
+		e.g.) "public_keys", "service_control", "firebase_auth"
+	*/
+	Name string `json:"name"`
+
+	Status HealthStatus `json:"status"`
+
+	/*
+		Human readable detail.
+	*/
+	Message string `json:"message,omitempty"`
+}
+
+/*
+Health report of SecurityContext.
+*/
+type HealthReport struct {
+	/*
+		Worst status of checks.
+	*/
+	Status HealthStatus `json:"status"`
+
+	/*
+		Public keys refreshed at.
+	*/
+	KeysRefreshedAt time.Time `json:"keysRefreshedAt"`
+
+	/*
+		Last public key refresh error class, or empty.
+		e.g.) "backend_unavailable"
+	*/
+	LastRefreshError string `json:"lastRefreshError,omitempty"`
+
+	Checks []*HealthCheck `json:"checks"`
+}
+
+/*
+Returns 503 if unready, otherwise 200.
+*/
+func (it *HealthReport) HttpStatusCode() int {
+	if it.Status == HealthUnready {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func (it *HealthReport) add(check *HealthCheck) {
+	it.Checks = append(it.Checks, check)
+	it.Status = it.Status.worse(check.Status)
+}
+
+/*
+HTTP handler for readiness probe.
+Response is HealthReport JSON, status is HealthReport.HttpStatusCode().
+
+e.g.)
+
+	http.Handle("/readyz", NewHealthHandler(securityContext))
+*/
+func NewHealthHandler(securityContext SecurityContext) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := securityContext.Health()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(report.HttpStatusCode())
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+/*
+Last call result of backend API.
+*/
+type backendStatus struct {
+	lock sync.Mutex
+
+	lastSuccessAt time.Time
+	lastErrorAt   time.Time
+	lastError     error
+}
+
+/*
+Record call result.
+Non-retryable error(e.g. invalid token) means backend is reachable.
+*/
+func (it *backendStatus) record(err error) {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	if err != nil && IsRetryableError(err) {
+		it.lastErrorAt = time.Now()
+		it.lastError = err
+	} else {
+		it.lastSuccessAt = time.Now()
+	}
+}
+
+/*
+Returns last error, if last call failed.
+*/
+func (it *backendStatus) failure() error {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	if it.lastError != nil && it.lastErrorAt.After(it.lastSuccessAt) {
+		return it.lastError
+	}
+	return nil
+}
+
+func (it *securityContextImpl) Health() *HealthReport {
+	thresholds := it.healthThresholds
+	if thresholds.DegradedKeyAge <= 0 {
+		thresholds.DegradedKeyAge = 2 * time.Hour
+	}
+	if thresholds.UnreadyKeyAge <= 0 {
+		thresholds.UnreadyKeyAge = 24 * time.Hour
+	}
+
+	report := &HealthReport{
+		Status: HealthOk,
+	}
+
+	// public keys
+	if it.gcp.disabled {
+		report.add(&HealthCheck{
+			Name:    "public_keys",
+			Status:  HealthOk,
+			Message: "disabled, Google Cloud Platform is not initialized",
+		})
+	} else if keyCache := it.gcp.serviceAccountPublicKeys; keyCache != nil {
+		refreshedAt, refreshErr, keys, refreshing := keyCache.status()
+		report.KeysRefreshedAt = refreshedAt
+		check := &HealthCheck{
+			Name:   "public_keys",
+			Status: HealthOk,
+		}
+		age := time.Since(refreshedAt)
+		switch {
+		case keys == 0:
+			check.Status = HealthUnready
+			check.Message = "public keys not loaded"
+		case refreshing && age > thresholds.UnreadyKeyAge:
+			check.Status = HealthUnready
+			check.Message = "public keys are stale"
+		case refreshing && age > thresholds.DegradedKeyAge:
+			check.Status = HealthDegraded
+			check.Message = "public keys are stale"
+		case refreshErr != nil:
+			check.Status = HealthDegraded
+			check.Message = "last refresh failed"
+		}
+		if refreshErr != nil {
+			report.LastRefreshError = getErrorClass(refreshErr)
+		}
+		report.add(check)
+	} else {
+		report.add(&HealthCheck{
+			Name:    "public_keys",
+			Status:  HealthUnready,
+			Message: "public key cache not initialized",
+		})
+	}
+
+	// ServiceControl
+	check := &HealthCheck{
+		Name:   "service_control",
+		Status: HealthOk,
+	}
+	if it.localApiKeys != nil {
+		check.Message = "disabled, use local API Key file"
+	} else if it.gcp.serviceControlClient == nil {
+		check.Status = HealthUnready
+		check.Message = "client not initialized"
+	} else if err := it.serviceControlStatus.failure(); err != nil {
+		check.Status = HealthDegraded
+		check.Message = getErrorClass(err)
+	}
+	report.add(check)
+
+	// Firebase Auth
+	check = &HealthCheck{
+		Name:   "firebase_auth",
+		Status: HealthOk,
+	}
+	if it.gcp.disabled {
+		check.Message = "disabled, Google Cloud Platform is not initialized"
+	} else if it.gcp.firebaseAuth == nil {
+		check.Status = HealthUnready
+		check.Message = "client not initialized"
+	} else if err := it.firebaseStatus.failure(); err != nil {
+		check.Status = HealthDegraded
+		check.Message = getErrorClass(err)
+	}
+	report.add(check)
+
+	return report
+}
@@ -0,0 +1,117 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/servicecontrol/v1"
+)
+
+func newHealthTestOwner() *securityContextImpl {
+	owner := newSecurityContextForTest()
+	keyCache := newGooglePublicKeyCache("http://localhost/keys", owner.logger, owner.tracer, owner.metrics)
+	keyCache.allKeys = map[string]*googlePublicKey{
+		"kid": {kid: "kid"},
+	}
+	keyCache.refreshedAt = time.Now()
+	owner.gcp.serviceAccountPublicKeys = keyCache
+	owner.gcp.firebaseAuth = &auth.Client{}
+	owner.gcp.serviceControlClient = &servicecontrol.Service{}
+	return owner
+}
+
+func TestSecurityContextImpl_Health(t *testing.T) {
+	owner := newHealthTestOwner()
+	report := owner.Health()
+	assert.Equal(t, HealthOk, report.Status)
+	assert.Equal(t, 3, len(report.Checks))
+	assert.Equal(t, http.StatusOK, report.HttpStatusCode())
+
+	// backend unavailable, error detail is not reported.
+	owner.serviceControlStatus.record(newVerificationError(ErrBackendUnavailable, "unavailable", errors.New("dial tcp 10.0.0.1:443")))
+	report = owner.Health()
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.Equal(t, "backend_unavailable", report.Checks[1].Message)
+	owner.serviceControlStatus.record(nil)
+	assert.Equal(t, HealthOk, owner.Health().Status)
+
+	// invalid token is not backend error.
+	owner.firebaseStatus.record(newVerificationError(ErrTokenExpired, "expired", nil))
+	assert.Equal(t, HealthOk, owner.Health().Status)
+
+	// refresh failed
+	owner.gcp.serviceAccountPublicKeys.lastRefreshError = newVerificationError(ErrBackendUnavailable, "refresh failed", errors.New("https://example.com/keys"))
+	report = owner.Health()
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.Equal(t, "backend_unavailable", report.LastRefreshError)
+	owner.gcp.serviceAccountPublicKeys.lastRefreshError = nil
+
+	// old keys, but refreshed on unknown key without background refresher.
+	owner.gcp.serviceAccountPublicKeys.refreshedAt = time.Now().Add(-48 * time.Hour)
+	assert.Equal(t, HealthOk, owner.Health().Status)
+
+	// stale keys
+	owner.gcp.serviceAccountPublicKeys.startRefresh(time.Hour)
+	defer func() {
+		assert.NoError(t, owner.gcp.serviceAccountPublicKeys.stopRefresh(context.Background()))
+	}()
+	report = owner.Health()
+	assert.Equal(t, HealthUnready, report.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, report.HttpStatusCode())
+
+	owner.healthThresholds = HealthThresholds{
+		DegradedKeyAge: time.Hour,
+		UnreadyKeyAge:  72 * time.Hour,
+	}
+	assert.Equal(t, HealthDegraded, owner.Health().Status)
+}
+
+func TestNewHealthHandler(t *testing.T) {
+	owner := newHealthTestOwner()
+	owner.gcp.firebaseAuth = nil
+
+	w := httptest.NewRecorder()
+	NewHealthHandler(owner).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"unready"`)
+}
+
+func TestSecurityContextImpl_Close(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	owner := newHealthTestOwner()
+	sink := NewMemoryAuditSink()
+	owner.audit = newAuditDispatcher([]AuditSink{sink}, 10, nil, owner.logger)
+	keyCache := newGooglePublicKeyCache(server.URL, owner.logger, owner.tracer, owner.metrics)
+	owner.gcp.serviceAccountPublicKeys = keyCache
+	keyCache.startRefresh(10 * time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&requests) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	owner.emitAudit(context.Background(), &AuditEvent{Outcome: AuditOutcomeAllowed})
+	assert.NoError(t, owner.Close(context.Background()))
+	assert.Equal(t, 1, len(sink.Events()))
+
+	// stopped, wait for canceled request.
+	time.Sleep(20 * time.Millisecond)
+	stopped := atomic.LoadInt32(&requests)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&requests))
+
+	// twice
+	assert.NoError(t, owner.Close(context.Background()))
+}
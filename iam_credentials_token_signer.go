@@ -0,0 +1,123 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/patrickmn/go-cache"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+/*
+Cached token is reused, if remaining lifetime is at least this ratio of requested lifetime.
+e.g.) 1h token is reused for 15 minutes.
+*/
+const iamSignedTokenMinRemainingRatio = 0.75
+
+/*
+Signer by IAM Credentials signJwt API.
+for key-less deployment(e.g. Cloud Run), private key is managed by Google.
+Signed token is verified by https://www.googleapis.com/robot/v1/metadata/x509/<email>.
+
+'Service Account Token Creator' role is required.
+see) https://cloud.google.com/iam/docs/reference/credentials/rest/v1/projects.serviceAccounts/signJwt
+*/
+type iamCredentialsTokenSigner struct {
+	serviceAccount string
+
+	service *iamcredentials.Service
+
+	/*
+		Signed tokens, key is sha256 of payload without time claims.
+	*/
+	signedTokens *cache.Cache
+}
+
+type iamSignedToken struct {
+	token    string
+	expireAt time.Time
+}
+
+/*
+Claims which are changed per sign, not used for cache key.
+*/
+var iamSignedTokenTimeClaims = map[string]bool{
+	"iat": true, "nbf": true, "exp": true,
+}
+
+/*
+Returns cache key of claims, without time claims.
+*/
+func getIamSignedTokenCacheKey(claims jwt.MapClaims) (string, error) {
+	values := map[string]interface{}{}
+	for key, value := range claims {
+		if !iamSignedTokenTimeClaims[key] {
+			values[key] = value
+		}
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func newIamCredentialsTokenSigner(ctx context.Context, serviceAccount string, opts ...option.ClientOption) (*iamCredentialsTokenSigner, error) {
+	service, err := iamcredentials.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("IAM Credentials init failed: %w", err)
+	}
+	return &iamCredentialsTokenSigner{
+		serviceAccount: serviceAccount,
+		service:        service,
+		signedTokens:   cache.New(cache.NoExpiration, time.Minute),
+	}, nil
+}
+
+func (it *iamCredentialsTokenSigner) sign(ctx context.Context, claims jwt.MapClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("JWT payload encode failed: %w", err)
+	}
+	cacheKey, err := getIamSignedTokenCacheKey(claims)
+	if err != nil {
+		return "", fmt.Errorf("JWT payload encode failed: %w", err)
+	}
+	exp, hasExp, _ := getTimeClaim(claims, "exp")
+	now, hasIat, _ := getTimeClaim(claims, "iat")
+	if !hasIat {
+		now = time.Now()
+	}
+
+	// cached token should not outlive requested 'exp', and should have enough remaining lifetime.
+	if cached, ok := it.signedTokens.Get(cacheKey); ok && hasExp {
+		cachedToken := cached.(*iamSignedToken)
+		minRemaining := time.Duration(float64(exp.Sub(now)) * iamSignedTokenMinRemainingRatio)
+		if !cachedToken.expireAt.After(exp) && cachedToken.expireAt.Sub(now) >= minRemaining {
+			return cachedToken.token, nil
+		}
+	}
+
+	name := fmt.Sprintf("projects/-/serviceAccounts/%v", it.serviceAccount)
+	resp, err := it.service.Projects.ServiceAccounts.SignJwt(name, &iamcredentials.SignJwtRequest{
+		Payload: string(payload),
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", newVerificationError(ErrBackendUnavailable, "IAM Credentials signJwt failed", err)
+	}
+
+	// cache until expiry, reused while remaining lifetime is enough.
+	if hasExp {
+		if ttl := exp.Sub(now); ttl > 0 {
+			it.signedTokens.Set(cacheKey, &iamSignedToken{token: resp.SignedJwt, expireAt: exp}, ttl)
+		}
+	}
+	return resp.SignedJwt, nil
+}
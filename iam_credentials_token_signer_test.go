@@ -0,0 +1,108 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eaglesakura/go-secure-backend/testutils"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+)
+
+func TestIamCredentialsTokenSigner(t *testing.T) {
+	ctx := context.Background()
+	fake := testutils.NewFakeIamCredentials()
+	defer fake.Close()
+
+	const email = "run@example.iam.gserviceaccount.com"
+	signer, err := newIamCredentialsTokenSigner(ctx, email, option.WithEndpoint(fake.Url()), option.WithoutAuthentication())
+	assert.NoError(t, err)
+
+	// key-less owner, like Cloud Run.
+	owner := newSecurityContextForTest()
+	owner.gcp.projectId = "example"
+	owner.gcp.clientEmail = email
+	owner.gcp.serviceAccountPublicKeys = newGooglePublicKeyCache(fake.KeyUrl(email), owner.logger, owner.tracer, owner.metrics)
+	owner.gcp.tokenSigner = signer
+
+	subject := newVerifiedFirebaseAuthTokenForTest("user-1", map[string]interface{}{})
+	subject.ExpireAt = time.Now().Add(time.Hour)
+	exchanged, err := owner.NewTokenExchanger().Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fake.SignCount())
+
+	// verified by x509 public key.
+	verifier := owner.NewFirebaseAuthVerifier()
+	verifier.AcceptOriginalTokenAudiences("https://orders.internal.example.com")
+	verifier.AcceptOriginalToken()
+	verified, err := verifier.Verify(ctx, exchanged.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", verified.User.Id)
+	assert.Equal(t, []string{email}, verified.Actors)
+
+	// cached, 'iat' and 'exp' are changed per exchange.
+	time.Sleep(1100 * time.Millisecond)
+	cached, err := owner.NewTokenExchanger().Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, exchanged.Token, cached.Token)
+	assert.Equal(t, exchanged.ExpireAt, cached.ExpireAt)
+	assert.Equal(t, 1, fake.SignCount())
+
+	// other scope
+	_, err = owner.NewTokenExchanger().Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+		Scopes:   []string{"orders.read"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fake.SignCount())
+
+	// cached token outlives subject.
+	subject.ExpireAt = time.Now().Add(time.Minute)
+	shorter, err := owner.NewTokenExchanger().Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, exchanged.Token, shorter.Token)
+	assert.Equal(t, subject.ExpireAt.Unix(), shorter.ExpireAt.Unix())
+	assert.Equal(t, 3, fake.SignCount())
+
+	// reused while remaining lifetime is enough for requested lifetime.
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": email,
+		"sub": email,
+		"aud": "https://orders.internal.example.com",
+		"uid": "user-2",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	first, err := signer.sign(ctx, claims)
+	assert.NoError(t, err)
+	claims["iat"] = now.Add(10 * time.Minute).Unix()
+	claims["exp"] = now.Add(70 * time.Minute).Unix()
+	reused, err := signer.sign(ctx, claims)
+	assert.NoError(t, err)
+	assert.Equal(t, first, reused)
+	assert.Equal(t, 4, fake.SignCount())
+
+	// remaining lifetime is too short, re-signed.
+	claims["iat"] = now.Add(50 * time.Minute).Unix()
+	claims["exp"] = now.Add(110 * time.Minute).Unix()
+	resigned, err := signer.sign(ctx, claims)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, resigned)
+	assert.Equal(t, 5, fake.SignCount())
+
+	// backend error
+	fake.Close()
+	claims["uid"] = "user-3"
+	_, err = signer.sign(ctx, claims)
+	assert.True(t, errors.Is(err, ErrBackendUnavailable), err)
+}
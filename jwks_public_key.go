@@ -0,0 +1,177 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+OpenID Connect discovery document.
+see) https://openid.net/specs/openid-connect-discovery-1_0.html
+*/
+type oidcDiscovery struct {
+	Issuer  string `json:"issuer"`
+	JwksUri string `json:"jwks_uri"`
+}
+
+/*
+JSON Web Key.
+see) https://www.rfc-editor.org/rfc/rfc7517
+*/
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+func getJson(ctx context.Context, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("request failed / %v: %w", url, err)
+	}
+	resp, err := publicKeyHttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed / %v: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download status error: %v / %v", resp.StatusCode, url)
+	}
+
+	body, err := readPublicKeyResponse(resp)
+	if err != nil {
+		return fmt.Errorf("read failed / %v: %w", url, err)
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("parse failed / %v: %w", url, err)
+	}
+	return nil
+}
+
+/*
+Returns OpenID Connect discovery document of issuer.
+*/
+func getOidcDiscovery(ctx context.Context, issuer string) (*oidcDiscovery, error) {
+	result := &oidcDiscovery{}
+	if err := getJson(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", result); err != nil {
+		return nil, fmt.Errorf("OpenID configuration load failed: %w", err)
+	}
+	if result.Issuer != issuer {
+		return nil, fmt.Errorf("OpenID configuration issuer mismatch: %v != %v", result.Issuer, issuer)
+	}
+	if len(result.JwksUri) == 0 {
+		return nil, fmt.Errorf("OpenID configuration has no jwks_uri: %v", issuer)
+	}
+	return result, nil
+}
+
+func decodeBase64UrlInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+func parseJsonWebKey(key *jsonWebKey) (*googlePublicKey, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBase64UrlInt(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWK(%v).n decode failed: %w", key.Kid, err)
+		}
+		e, err := decodeBase64UrlInt(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("JWK(%v).e decode failed: %w", key.Kid, err)
+		}
+		return &googlePublicKey{
+			kid:       key.Kid,
+			publicKey: &rsa.PublicKey{N: n, E: int(e.Int64())},
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("JWK(%v) unsupported curve: %v", key.Kid, key.Crv)
+		}
+		x, err := decodeBase64UrlInt(key.X)
+		if err != nil {
+			return nil, fmt.Errorf("JWK(%v).x decode failed: %w", key.Kid, err)
+		}
+		y, err := decodeBase64UrlInt(key.Y)
+		if err != nil {
+			return nil, fmt.Errorf("JWK(%v).y decode failed: %w", key.Kid, err)
+		}
+		return &googlePublicKey{
+			kid:       key.Kid,
+			publicKey: &ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		}, nil
+	default:
+		return nil, fmt.Errorf("JWK(%v) unsupported kty: %v", key.Kid, key.Kty)
+	}
+}
+
+/*
+Returns signing keys from JWKS endpoint.
+Unsupported keys(e.g. encryption key) are skipped.
+*/
+func getJwksPublicKeys(ctx context.Context, url string) ([]*googlePublicKey, error) {
+	jwks := struct {
+		Keys []*jsonWebKey `json:"keys"`
+	}{}
+	if err := getJson(ctx, url, &jwks); err != nil {
+		return nil, fmt.Errorf("JWKS load failed: %w", err)
+	}
+
+	result := make([]*googlePublicKey, 0, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if len(key.Use) > 0 && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := parseJsonWebKey(key)
+		if err != nil {
+			continue
+		}
+		result = append(result, publicKey)
+	}
+	return result, nil
+}
+
+/*
+New public key cache by JWKS endpoint.
+Refresh semantics are same as Google public key cache.
+*/
+func newJwksPublicKeyCache(jwksUri string, logger *slog.Logger, tracer trace.Tracer, metrics Metrics) *googlePublicKeyCache {
+	result := newGooglePublicKeyCache(jwksUri, logger, tracer, metrics)
+	result.fetchKeys = getJwksPublicKeys
+	return result
+}
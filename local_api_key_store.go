@@ -0,0 +1,239 @@
+package secure_backend
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Local API Key file.
+
+e.g.)
+
+	{
+	  "keys": [
+	    {
+	      "sha512": "sha512 hex digest of API Key",
+	      "owner": "customer-a",
+	      "services": ["your-service.example.com"],
+	      "expireAt": "2030-01-01T00:00:00Z",
+	      "allowedIps": ["192.0.2.0/24", "198.51.100.1"]
+	    }
+	  ]
+	}
+*/
+type localApiKeyFile struct {
+	Keys []*localApiKey `json:"keys"`
+}
+
+type localApiKey struct {
+	/*
+		Hashed API Key, see HashLocalApiKey().
+	*/
+	Sha512 string `json:"sha512"`
+
+	/*
+		API Key owner.
+	*/
+	Owner string `json:"owner"`
+
+	/*
+		Allowed service names.
+		If empty, then all services are allowed.
+	*/
+	Services []string `json:"services,omitempty"`
+
+	/*
+		Expire time.
+		If nil, then never expire.
+	*/
+	ExpireAt *time.Time `json:"expireAt,omitempty"`
+
+	/*
+		Allowed client IP address or CIDR.
+		If empty, then all addresses are allowed.
+	*/
+	AllowedIps []string `json:"allowedIps,omitempty"`
+
+	allowedNetworks []*net.IPNet
+}
+
+/*
+Returns hashed API Key for local API Key file.
+*/
+func HashLocalApiKey(apiKey string) string {
+	return sha512sum(apiKey)
+}
+
+func (it *localApiKey) init() error {
+	if len(it.Sha512) != 128 {
+		return fmt.Errorf("invalid sha512 hash(%v)", it.Owner)
+	}
+	it.Sha512 = strings.ToLower(it.Sha512)
+
+	for _, allowed := range it.AllowedIps {
+		if !strings.Contains(allowed, "/") {
+			ip := net.ParseIP(allowed)
+			if ip == nil {
+				return fmt.Errorf("invalid IP address(%v): %v", it.Owner, allowed)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			allowed = fmt.Sprintf("%v/%v", allowed, bits)
+		}
+
+		_, network, err := net.ParseCIDR(allowed)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR(%v): %w", it.Owner, err)
+		}
+		it.allowedNetworks = append(it.allowedNetworks, network)
+	}
+	return nil
+}
+
+func (it *localApiKey) allowService(serviceName string) bool {
+	if len(it.Services) == 0 {
+		return true
+	}
+	for _, service := range it.Services {
+		if service == serviceName {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *localApiKey) allowIp(clientIp string) bool {
+	if len(it.allowedNetworks) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIp)
+	if ip == nil {
+		return false
+	}
+	for _, network := range it.allowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Local API Key file, reload on change.
+*/
+type localApiKeyStore struct {
+	logger *slog.Logger
+
+	path string
+
+	/*
+		File check interval.
+	*/
+	checkInterval time.Duration
+
+	lock        *sync.Mutex
+	keys        []*localApiKey
+	modTime     time.Time
+	size        int64
+	lastCheckAt time.Time
+}
+
+func newLocalApiKeyStore(path string, logger *slog.Logger) *localApiKeyStore {
+	return &localApiKeyStore{
+		logger:        logger,
+		path:          path,
+		checkInterval: 5 * time.Second,
+		lock:          new(sync.Mutex),
+	}
+}
+
+func (it *localApiKeyStore) load() error {
+	stat, err := os.Stat(it.path)
+	if err != nil {
+		return fmt.Errorf("local API Key file stat failed: %w", err)
+	}
+
+	bytes, err := os.ReadFile(it.path)
+	if err != nil {
+		return fmt.Errorf("local API Key file load failed: %w", err)
+	}
+
+	file := localApiKeyFile{}
+	if err := json.Unmarshal(bytes, &file); err != nil {
+		return fmt.Errorf("local API Key file parse failed: %w", err)
+	}
+	for _, key := range file.Keys {
+		if err := key.init(); err != nil {
+			return fmt.Errorf("local API Key file parse failed: %w", err)
+		}
+	}
+
+	it.keys = file.Keys
+	it.modTime = stat.ModTime()
+	it.size = stat.Size()
+	it.logger.Info("local API Key file loaded", slog.String("path", it.path), slog.Int("keys", len(it.keys)))
+	return nil
+}
+
+/*
+Reload file if modified.
+If reload failed, then keep current keys.
+*/
+func (it *localApiKeyStore) reloadIfModified(now time.Time) {
+	if now.Sub(it.lastCheckAt) < it.checkInterval {
+		return
+	}
+	it.lastCheckAt = now
+
+	stat, err := os.Stat(it.path)
+	if err != nil {
+		it.logger.Error("local API Key file stat failed", slog.String("path", it.path), slog.Any("error", err))
+		return
+	}
+	if stat.ModTime().Equal(it.modTime) && stat.Size() == it.size {
+		return
+	}
+
+	if err := it.load(); err != nil {
+		it.logger.Error("local API Key file reload failed", slog.String("path", it.path), slog.Any("error", err))
+	}
+}
+
+/*
+Returns count of loaded keys.
+*/
+func (it *localApiKeyStore) count() int {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	return len(it.keys)
+}
+
+/*
+Find API Key.
+All keys are compared in constant time.
+*/
+func (it *localApiKeyStore) find(apiKey string) *localApiKey {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	it.reloadIfModified(time.Now())
+
+	hash := []byte(HashLocalApiKey(apiKey))
+	var result *localApiKey
+	for _, key := range it.keys {
+		if subtle.ConstantTimeCompare(hash, []byte(key.Sha512)) == 1 && result == nil {
+			result = key
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package secure_backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+/*
+GoogleApiKeyVerifier implementation by local API Key file.
+for non-GCP(on-premises) deployment.
+*/
+type localApiKeyVerifierImpl struct {
+	owner *securityContextImpl
+
+	logger *slog.Logger
+
+	/*
+		Custom service name.
+	*/
+	serviceName string
+}
+
+func (it *localApiKeyVerifierImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
+}
+
+func (it *localApiKeyVerifierImpl) SetSlogLogger(logger *slog.Logger) {
+	it.logger = logger
+}
+
+func (it *localApiKeyVerifierImpl) SetServiceName(serviceName string) {
+	it.serviceName = serviceName
+}
+
+func (it *localApiKeyVerifierImpl) Verify(ctx context.Context, apiKey string) (*VerifiedGoogleApiKey, error) {
+	return it.VerifyRequest(ctx, &GoogleApiKeyVerifyRequest{
+		ApiKey: apiKey,
+	})
+}
+
+func (it *localApiKeyVerifierImpl) VerifyRequest(ctx context.Context, request *GoogleApiKeyVerifyRequest) (result *VerifiedGoogleApiKey, err error) {
+	startAt := time.Now()
+	defer func() {
+		it.owner.getMetrics().ObserveVerification(metricsVerifierLocalApiKey, getVerificationResult(err), time.Since(startAt))
+		it.owner.recentErrors.add(metricsVerifierLocalApiKey, err)
+
+		principal := it.owner.redaction.secret(request.ApiKey)
+		if result != nil && len(result.Owner) > 0 {
+			principal = result.Owner
+		}
+		outcome, reasonCode := getAuditOutcome(err)
+		it.owner.emitAudit(ctx, &AuditEvent{
+			Principal:  principal,
+			Method:     "local_file",
+			Verifier:   metricsVerifierLocalApiKey,
+			Outcome:    outcome,
+			ReasonCode: reasonCode,
+			ClientIp:   request.ClientIp,
+		})
+	}()
+
+	serviceName := it.serviceName
+	if len(serviceName) == 0 && len(it.owner.gcp.projectId) > 0 {
+		serviceName = fmt.Sprintf("%v.appspot.com", it.owner.gcp.projectId)
+	}
+
+	reject := func(code string, detail string) error {
+		it.logger.Info("API Key validation error",
+			slog.String("service", serviceName),
+			slog.String("code", code),
+			slog.String("detail", detail),
+			slog.String("outcome", "denied"))
+		return &GoogleApiKeyCheckError{
+			ServiceName: serviceName,
+			Errors: []*GoogleApiKeyCheckErrorDetail{
+				{Code: code, Detail: detail},
+			},
+		}
+	}
+
+	key := it.owner.localApiKeys.find(request.ApiKey)
+	if key == nil {
+		return nil, reject(GoogleApiKeyErrorApiKeyInvalid, "API Key not found in local file")
+	} else if key.ExpireAt != nil && !it.owner.now().Before(*key.ExpireAt) {
+		return nil, reject(GoogleApiKeyErrorApiKeyExpired, fmt.Sprintf("API Key expired at %v", key.ExpireAt))
+	} else if !key.allowService(serviceName) {
+		return nil, reject(GoogleApiKeyErrorApiTargetBlocked, fmt.Sprintf("service not allowed: %v", serviceName))
+	} else if !key.allowIp(request.ClientIp) {
+		return nil, reject(GoogleApiKeyErrorIpAddressBlocked, fmt.Sprintf("IP address not allowed: %v", request.ClientIp))
+	}
+
+	it.logger.Debug("Valid API Key from local file",
+		slog.String("service", serviceName),
+		slog.String("owner", key.Owner),
+		slog.String("outcome", "allowed"))
+	return &VerifiedGoogleApiKey{
+		ServiceName: serviceName,
+		Owner:       key.Owner,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLocalApiKeyVerifierForTest(t *testing.T, json string) (*securityContextImpl, string) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	assert.NoError(t, os.WriteFile(path, []byte(json), 0600))
+
+	owner := newSecurityContextForTest()
+	owner.gcp.projectId = "example"
+	owner.localApiKeys = newLocalApiKeyStore(path, owner.logger)
+	assert.NoError(t, owner.localApiKeys.load())
+	return owner, path
+}
+
+func TestLocalApiKeyVerifierImpl_Verify(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := newLocalApiKeyVerifierForTest(t, fmt.Sprintf(`{
+		"keys": [
+			{"sha512": "%v", "owner": "customer-a", "services": ["example.appspot.com"], "allowedIps": ["192.0.2.0/24"]},
+			{"sha512": "%v", "owner": "customer-b", "expireAt": "2000-01-01T00:00:00Z"},
+			{"sha512": "%v", "owner": "customer-c", "services": ["other.example.com"]}
+		]
+	}`, HashLocalApiKey("key-a"), HashLocalApiKey("key-b"), HashLocalApiKey("key-c")))
+	verifier := owner.NewGoogleApiKeyVerifier()
+
+	verified, err := verifier.VerifyRequest(ctx, &GoogleApiKeyVerifyRequest{
+		ApiKey:   "key-a",
+		ClientIp: "192.0.2.10",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "customer-a", verified.Owner)
+	assert.Equal(t, "example.appspot.com", verified.ServiceName)
+
+	var checkErr *GoogleApiKeyCheckError
+
+	// IP restriction
+	_, err = verifier.VerifyRequest(ctx, &GoogleApiKeyVerifyRequest{
+		ApiKey:   "key-a",
+		ClientIp: "198.51.100.1",
+	})
+	assert.True(t, errors.As(err, &checkErr))
+	assert.True(t, checkErr.HasCode(GoogleApiKeyErrorIpAddressBlocked))
+
+	// expired
+	_, err = verifier.Verify(ctx, "key-b")
+	assert.True(t, errors.As(err, &checkErr))
+	assert.True(t, checkErr.HasCode(GoogleApiKeyErrorApiKeyExpired))
+
+	// service restriction
+	_, err = verifier.Verify(ctx, "key-c")
+	assert.True(t, errors.As(err, &checkErr))
+	assert.True(t, checkErr.HasCode(GoogleApiKeyErrorApiTargetBlocked))
+
+	// unknown key
+	_, err = verifier.Verify(ctx, "unknown")
+	assert.True(t, errors.Is(err, ErrInvalidApiKey))
+}
+
+func TestLocalApiKeyVerifierImpl_Verify_reload(t *testing.T) {
+	ctx := context.Background()
+	owner, path := newLocalApiKeyVerifierForTest(t, fmt.Sprintf(`{
+		"keys": [{"sha512": "%v", "owner": "old"}]
+	}`, HashLocalApiKey("old-key")))
+	verifier := owner.NewGoogleApiKeyVerifier()
+
+	_, err := verifier.Verify(ctx, "old-key")
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(`{
+		"keys": [{"sha512": "%v", "owner": "new-owner"}]
+	}`, HashLocalApiKey("new-key"))), 0600))
+	owner.localApiKeys.lastCheckAt = time.Time{}
+
+	verified, err := verifier.Verify(ctx, "new-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-owner", verified.Owner)
+
+	_, err = verifier.Verify(ctx, "old-key")
+	assert.Error(t, err)
+}
+
+func TestNewSecurityContext_localApiKeyFile(t *testing.T) {
+	// on-premises, without GCP credentials.
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	assert.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(`{
+		"keys": [{"sha512": "%v", "owner": "customer-a"}]
+	}`, HashLocalApiKey("key-a"))), 0600))
+
+	sc, err := NewSecurityContext(ctx, &SecurityContextConfigs{
+		LocalApiKeyFile:    path,
+		KeyRefreshInterval: time.Minute,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, sc.Close(ctx))
+	}()
+
+	verified, err := sc.NewGoogleApiKeyVerifier().Verify(ctx, "key-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "customer-a", verified.Owner)
+	assert.Equal(t, HealthOk, sc.Health().Status)
+
+	// Firebase is not available.
+	_, err = sc.NewFirebaseAuthVerifier().Verify(ctx, "eyJhbGciOiJub25lIn0.eyJzdWIiOiJ1c2VyIn0.")
+	assert.True(t, errors.Is(err, ErrBackendUnavailable), err)
+	_, err = sc.NewCustomClaimsManager().Get(ctx, "", "user")
+	assert.True(t, errors.Is(err, ErrBackendUnavailable), err)
+}
@@ -1,10 +1,17 @@
 package secure_backend
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"strings"
 )
 
+/*
+String only logger.
+Use SecurityContextConfigs.SlogLogger for structured logging.
+*/
 type Logger struct {
 	Info  func(message string)
 	Error func(message string)
@@ -25,3 +32,81 @@ func (it *Logger) logError(message string) {
 		log.Println(fmt.Sprintf("go-secure-backend.Error: %v", message))
 	}
 }
+
+/*
+slog.Handler adapter for Logger.
+Record is formatted to "message key=value ...".
+Debug level is dropped, Info level is sent to Logger.Info, Warn and Error levels are sent to Logger.Error.
+*/
+type loggerHandler struct {
+	logger *Logger
+	attrs  []slog.Attr
+	group  string
+}
+
+func (it *loggerHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func appendLoggerAttr(builder *strings.Builder, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, child := range value.Group() {
+			appendLoggerAttr(builder, prefix+attr.Key+".", child)
+		}
+		return
+	}
+	builder.WriteString(fmt.Sprintf(" %v%v=%v", prefix, attr.Key, value))
+}
+
+func (it *loggerHandler) Handle(ctx context.Context, record slog.Record) error {
+	builder := &strings.Builder{}
+	builder.WriteString(record.Message)
+	for _, attr := range it.attrs {
+		appendLoggerAttr(builder, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		appendLoggerAttr(builder, it.group, attr)
+		return true
+	})
+
+	if record.Level >= slog.LevelWarn {
+		it.logger.logError(builder.String())
+	} else {
+		it.logger.logInfo(builder.String())
+	}
+	return nil
+}
+
+func (it *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := &loggerHandler{
+		logger: it.logger,
+		group:  it.group,
+	}
+	result.attrs = append(result.attrs, it.attrs...)
+	for _, attr := range attrs {
+		attr.Key = it.group + attr.Key
+		result.attrs = append(result.attrs, attr)
+	}
+	return result
+}
+
+func (it *loggerHandler) WithGroup(name string) slog.Handler {
+	return &loggerHandler{
+		logger: it.logger,
+		attrs:  it.attrs,
+		group:  it.group + name + ".",
+	}
+}
+
+/*
+Returns slog.Logger by Logger.
+*/
+func newSlogLogger(logger *Logger) *slog.Logger {
+	if logger == nil {
+		logger = &Logger{}
+	}
+	return slog.New(&loggerHandler{
+		logger: logger,
+	})
+}
@@ -0,0 +1,34 @@
+package secure_backend
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newSlogLogger(t *testing.T) {
+	var infos, errors []string
+	logger := newSlogLogger(&Logger{
+		Info: func(message string) {
+			infos = append(infos, message)
+		},
+		Error: func(message string) {
+			errors = append(errors, message)
+		},
+	})
+
+	logger = logger.With(slog.String("path", "firebase"))
+	logger.Debug("debug message")
+	logger.Info("token verified", slog.String("kid", "key-1"))
+	logger.WithGroup("key").Warn("refresh failed", slog.Int("count", 2))
+	logger.Error("fatal", slog.Group("api_key", slog.String("service", "example")))
+
+	assert.Equal(t, []string{
+		"token verified path=firebase kid=key-1",
+	}, infos)
+	assert.Equal(t, []string{
+		"refresh failed path=firebase key.count=2",
+		"fatal path=firebase api_key.service=example",
+	}, errors)
+}
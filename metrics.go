@@ -0,0 +1,68 @@
+package secure_backend
+
+import "time"
+
+/*
+Verifier names for metrics.
+*/
+const (
+	metricsVerifierFirebaseAuth     = "firebase_auth"
+	metricsVerifierGoogleApiKey     = "google_api_key"
+	metricsVerifierLocalApiKey      = "local_api_key"
+	metricsVerifierRequestSignature = "request_signature"
+	metricsVerifierOidc             = "oidc"
+	metricsVerifierGithubActions    = "github_actions"
+)
+
+/*
+Metrics reporter.
+see) NewPrometheusMetrics(), NewOpenTelemetryMetrics()
+*/
+type Metrics interface {
+	// Verification finished.
+	// verifier is "firebase_auth", "google_api_key", "local_api_key", "request_signature", "oidc" or "github_actions".
+	// result is "allowed" or error class(e.g. "token_expired").
+	ObserveVerification(verifier string, result string, duration time.Duration)
+
+	// Verified API Key cache lookup.
+	ObserveApiKeyCache(hit bool)
+
+	// Public key refresh finished.
+	// err is nil if succeeded.
+	ObserveKeyRefresh(source string, err error)
+
+	// Number of loaded public keys.
+	SetLoadedKeys(source string, count int)
+}
+
+type noopMetrics struct {
+}
+
+func (it *noopMetrics) ObserveVerification(verifier string, result string, duration time.Duration) {
+}
+
+func (it *noopMetrics) ObserveApiKeyCache(hit bool) {
+}
+
+func (it *noopMetrics) ObserveKeyRefresh(source string, err error) {
+}
+
+func (it *noopMetrics) SetLoadedKeys(source string, count int) {
+}
+
+/*
+Returns verification result for metrics.
+*/
+func getVerificationResult(err error) string {
+	if err != nil {
+		return getErrorClass(err)
+	}
+	return "allowed"
+}
+
+func (it *securityContextImpl) getMetrics() Metrics {
+	if it.metrics == nil {
+		it.metrics = &noopMetrics{}
+	}
+	return it.metrics
+}
@@ -0,0 +1,108 @@
+package secure_backend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type openTelemetryMetrics struct {
+	verifications        metric.Int64Counter
+	verificationDuration metric.Float64Histogram
+	apiKeyCache          metric.Int64Counter
+	keyRefreshes         metric.Int64Counter
+
+	lock       *sync.Mutex
+	loadedKeys map[string]int
+}
+
+/*
+New OpenTelemetry metrics reporter.
+
+Instruments:
+
+  - secure_backend.verifications{verifier, result}
+  - secure_backend.verification.duration{verifier} (seconds)
+  - secure_backend.api_key_cache.lookups{result="hit"|"miss"}
+  - secure_backend.key_refreshes{source, result="success"|"failure"}
+  - secure_backend.loaded_keys{source}
+*/
+func NewOpenTelemetryMetrics(meter metric.Meter) (Metrics, error) {
+	result := &openTelemetryMetrics{
+		lock:       new(sync.Mutex),
+		loadedKeys: map[string]int{},
+	}
+
+	var err error
+	if result.verifications, err = meter.Int64Counter("secure_backend.verifications",
+		metric.WithDescription("Number of verifications by verifier and result.")); err != nil {
+		return nil, err
+	}
+	if result.verificationDuration, err = meter.Float64Histogram("secure_backend.verification.duration",
+		metric.WithDescription("Verification latency."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if result.apiKeyCache, err = meter.Int64Counter("secure_backend.api_key_cache.lookups",
+		metric.WithDescription("Number of verified API Key cache lookups.")); err != nil {
+		return nil, err
+	}
+	if result.keyRefreshes, err = meter.Int64Counter("secure_backend.key_refreshes",
+		metric.WithDescription("Number of public key refreshes.")); err != nil {
+		return nil, err
+	}
+	if _, err = meter.Int64ObservableGauge("secure_backend.loaded_keys",
+		metric.WithDescription("Number of loaded public keys."),
+		metric.WithInt64Callback(result.observeLoadedKeys)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (it *openTelemetryMetrics) observeLoadedKeys(ctx context.Context, observer metric.Int64Observer) error {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	for source, count := range it.loadedKeys {
+		observer.Observe(int64(count), metric.WithAttributes(attribute.String("source", source)))
+	}
+	return nil
+}
+
+func (it *openTelemetryMetrics) ObserveVerification(verifier string, result string, duration time.Duration) {
+	ctx := context.Background()
+	it.verifications.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("verifier", verifier),
+		attribute.String("result", result),
+	))
+	it.verificationDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("verifier", verifier),
+	))
+}
+
+func (it *openTelemetryMetrics) ObserveApiKeyCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	it.apiKeyCache.Add(context.Background(), 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+func (it *openTelemetryMetrics) ObserveKeyRefresh(source string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	it.keyRefreshes.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("source", source),
+		attribute.String("result", result),
+	))
+}
+
+func (it *openTelemetryMetrics) SetLoadedKeys(source string, count int) {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	it.loadedKeys[source] = count
+}
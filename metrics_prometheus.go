@@ -0,0 +1,90 @@
+package secure_backend
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type prometheusMetrics struct {
+	verifications        *prometheus.CounterVec
+	verificationDuration *prometheus.HistogramVec
+	apiKeyCache          *prometheus.CounterVec
+	keyRefreshes         *prometheus.CounterVec
+	loadedKeys           *prometheus.GaugeVec
+}
+
+/*
+New Prometheus metrics reporter.
+
+Metrics:
+
+  - secure_backend_verifications_total{verifier, result}
+  - secure_backend_verification_duration_seconds{verifier}
+  - secure_backend_api_key_cache_lookups_total{result="hit"|"miss"}
+  - secure_backend_key_refreshes_total{source, result="success"|"failure"}
+  - secure_backend_loaded_keys{source}
+*/
+func NewPrometheusMetrics(registerer prometheus.Registerer) (Metrics, error) {
+	result := &prometheusMetrics{
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "secure_backend_verifications_total",
+			Help: "Number of verifications by verifier and result.",
+		}, []string{"verifier", "result"}),
+		verificationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "secure_backend_verification_duration_seconds",
+			Help:    "Verification latency.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"verifier"}),
+		apiKeyCache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "secure_backend_api_key_cache_lookups_total",
+			Help: "Number of verified API Key cache lookups.",
+		}, []string{"result"}),
+		keyRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "secure_backend_key_refreshes_total",
+			Help: "Number of public key refreshes.",
+		}, []string{"source", "result"}),
+		loadedKeys: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "secure_backend_loaded_keys",
+			Help: "Number of loaded public keys.",
+		}, []string{"source"}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		result.verifications,
+		result.verificationDuration,
+		result.apiKeyCache,
+		result.keyRefreshes,
+		result.loadedKeys,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (it *prometheusMetrics) ObserveVerification(verifier string, result string, duration time.Duration) {
+	it.verifications.WithLabelValues(verifier, result).Inc()
+	it.verificationDuration.WithLabelValues(verifier).Observe(duration.Seconds())
+}
+
+func (it *prometheusMetrics) ObserveApiKeyCache(hit bool) {
+	if hit {
+		it.apiKeyCache.WithLabelValues("hit").Inc()
+	} else {
+		it.apiKeyCache.WithLabelValues("miss").Inc()
+	}
+}
+
+func (it *prometheusMetrics) ObserveKeyRefresh(source string, err error) {
+	if err != nil {
+		it.keyRefreshes.WithLabelValues(source, "failure").Inc()
+	} else {
+		it.keyRefreshes.WithLabelValues(source, "success").Inc()
+	}
+}
+
+func (it *prometheusMetrics) SetLoadedKeys(source string, count int) {
+	it.loadedKeys.WithLabelValues(source).Set(float64(count))
+}
@@ -0,0 +1,129 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNewPrometheusMetrics(t *testing.T) {
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+	metrics, err := NewPrometheusMetrics(registry)
+	assert.NoError(t, err)
+
+	owner, _ := newLocalApiKeyVerifierForTest(t, fmt.Sprintf(`{
+		"keys": [{"sha512": "%v", "owner": "customer-a"}]
+	}`, HashLocalApiKey("key-a")))
+	owner.metrics = metrics
+	verifier := owner.NewGoogleApiKeyVerifier()
+
+	_, err = verifier.Verify(ctx, "key-a")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, "unknown")
+	assert.Error(t, err)
+
+	metrics.ObserveKeyRefresh("https://example.com/keys", nil)
+	metrics.SetLoadedKeys("https://example.com/keys", 3)
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP secure_backend_verifications_total Number of verifications by verifier and result.
+# TYPE secure_backend_verifications_total counter
+secure_backend_verifications_total{result="allowed",verifier="local_api_key"} 1
+secure_backend_verifications_total{result="invalid_api_key",verifier="local_api_key"} 1
+# HELP secure_backend_key_refreshes_total Number of public key refreshes.
+# TYPE secure_backend_key_refreshes_total counter
+secure_backend_key_refreshes_total{result="success",source="https://example.com/keys"} 1
+# HELP secure_backend_loaded_keys Number of loaded public keys.
+# TYPE secure_backend_loaded_keys gauge
+secure_backend_loaded_keys{source="https://example.com/keys"} 3
+`), "secure_backend_verifications_total", "secure_backend_key_refreshes_total", "secure_backend_loaded_keys"))
+}
+
+/*
+Returns sum data points of metric, by attributes.
+*/
+func getOpenTelemetryMetricValues(t *testing.T, data *metricdata.ResourceMetrics, name string) map[string]int64 {
+	result := map[string]int64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != name {
+				continue
+			}
+			var points []metricdata.DataPoint[int64]
+			switch value := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				points = value.DataPoints
+			case metricdata.Gauge[int64]:
+				points = value.DataPoints
+			default:
+				t.Fatalf("unexpected metric data(%v): %T", name, m.Data)
+			}
+			for _, point := range points {
+				result[point.Attributes.Encoded(attribute.DefaultEncoder())] = point.Value
+			}
+		}
+	}
+	return result
+}
+
+func TestNewOpenTelemetryMetrics(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	metrics, err := NewOpenTelemetryMetrics(provider.Meter("secure_backend"))
+	assert.NoError(t, err)
+
+	owner, _ := newLocalApiKeyVerifierForTest(t, fmt.Sprintf(`{
+		"keys": [{"sha512": "%v", "owner": "customer-a"}]
+	}`, HashLocalApiKey("key-a")))
+	owner.metrics = metrics
+	verifier := owner.NewGoogleApiKeyVerifier()
+
+	_, err = verifier.Verify(ctx, "key-a")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, "unknown")
+	assert.Error(t, err)
+
+	metrics.ObserveApiKeyCache(true)
+	metrics.ObserveKeyRefresh("https://example.com/keys", nil)
+	metrics.ObserveKeyRefresh("https://example.com/keys", errors.New("unavailable"))
+	metrics.SetLoadedKeys("https://example.com/keys", 3)
+
+	data := &metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(ctx, data))
+
+	assert.Equal(t, map[string]int64{
+		"result=allowed,verifier=local_api_key":         1,
+		"result=invalid_api_key,verifier=local_api_key": 1,
+	}, getOpenTelemetryMetricValues(t, data, "secure_backend.verifications"))
+	assert.Equal(t, map[string]int64{
+		"result=hit": 1,
+	}, getOpenTelemetryMetricValues(t, data, "secure_backend.api_key_cache.lookups"))
+	assert.Equal(t, map[string]int64{
+		"result=success,source=https://example.com/keys": 1,
+		"result=failure,source=https://example.com/keys": 1,
+	}, getOpenTelemetryMetricValues(t, data, "secure_backend.key_refreshes"))
+	assert.Equal(t, map[string]int64{
+		"source=https://example.com/keys": 3,
+	}, getOpenTelemetryMetricValues(t, data, "secure_backend.loaded_keys"))
+
+	// latency histogram
+	var histogram metricdata.Histogram[float64]
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		if m.Name == "secure_backend.verification.duration" {
+			histogram = m.Data.(metricdata.Histogram[float64])
+		}
+	}
+	assert.Equal(t, 1, len(histogram.DataPoints))
+	assert.Equal(t, uint64(2), histogram.DataPoints[0].Count)
+}
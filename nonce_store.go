@@ -0,0 +1,117 @@
+package secure_backend
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+Used nonce store, for replay protection.
+*/
+type NonceStore interface {
+	// Add nonce until expireAt.
+	// Returns false if nonce is already used.
+	Add(ctx context.Context, nonce string, expireAt time.Time) (bool, error)
+}
+
+type memoryNonce struct {
+	nonce    string
+	expireAt time.Time
+}
+
+/*
+Min-heap of nonces by expireAt.
+expireAt is by client timestamp, so nonces are not added in order.
+*/
+type memoryNonceQueue []*memoryNonce
+
+func (it memoryNonceQueue) Len() int {
+	return len(it)
+}
+
+func (it memoryNonceQueue) Less(i, j int) bool {
+	return it[i].expireAt.Before(it[j].expireAt)
+}
+
+func (it memoryNonceQueue) Swap(i, j int) {
+	it[i], it[j] = it[j], it[i]
+}
+
+func (it *memoryNonceQueue) Push(value interface{}) {
+	*it = append(*it, value.(*memoryNonce))
+}
+
+func (it *memoryNonceQueue) Pop() interface{} {
+	old := *it
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*it = old[:len(old)-1]
+	return last
+}
+
+type memoryNonceStore struct {
+	lock     *sync.Mutex
+	now      func() time.Time
+	capacity int
+	nonces   map[string]time.Time
+	queue    memoryNonceQueue
+}
+
+/*
+New in-memory nonce store.
+Store holds up to capacity nonces, and expired nonce is removed.
+If store is full of not expired nonces, then Add() returns ErrBackendUnavailable.
+Expiration is checked by system clock.
+*/
+func NewMemoryNonceStore(capacity int) NonceStore {
+	return newMemoryNonceStore(capacity, time.Now)
+}
+
+/*
+New in-memory nonce store, expiration is checked by now.
+*/
+func newMemoryNonceStore(capacity int, now func() time.Time) *memoryNonceStore {
+	return &memoryNonceStore{
+		lock:     new(sync.Mutex),
+		now:      now,
+		capacity: capacity,
+		nonces:   map[string]time.Time{},
+	}
+}
+
+func (it *memoryNonceStore) removeExpired(now time.Time) {
+	for len(it.queue) > 0 {
+		oldest := it.queue[0]
+		if oldest.expireAt.After(now) {
+			return
+		}
+		heap.Pop(&it.queue)
+		if expireAt, ok := it.nonces[oldest.nonce]; ok && expireAt.Equal(oldest.expireAt) {
+			delete(it.nonces, oldest.nonce)
+		}
+	}
+}
+
+func (it *memoryNonceStore) Add(ctx context.Context, nonce string, expireAt time.Time) (bool, error) {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	now := it.now()
+	if expireAt, ok := it.nonces[nonce]; ok && expireAt.After(now) {
+		return false, nil
+	}
+
+	it.removeExpired(now)
+	if len(it.nonces) >= it.capacity {
+		return false, newVerificationError(ErrBackendUnavailable, "nonce store is full", nil)
+	}
+
+	it.nonces[nonce] = expireAt
+	heap.Push(&it.queue, &memoryNonce{
+		nonce:    nonce,
+		expireAt: expireAt,
+	})
+	return true, nil
+}
@@ -0,0 +1,47 @@
+package secure_backend
+
+import (
+	"context"
+	"log/slog"
+)
+
+/*
+OpenID Connect provider settings.
+*/
+type OidcVerifierConfigs struct {
+	/*
+		Issuer URL, must be same as 'iss' claim.
+		'.well-known/openid-configuration' is loaded from this URL.
+		e.g.) "https://your-tenant.auth0.com/", "https://your-org.okta.com"
+	*/
+	IssuerUrl string
+
+	/*
+		Accepted audiences, token must have one of them.
+		e.g.) client id, API identifier
+	*/
+	Audiences []string
+
+	/*
+		Accepted authorized parties('azp' claim).
+		If this value is not empty, then token must have one of them.
+		If this value is empty and token has multiple audiences, then 'azp' must be one of Audiences.
+	*/
+	AuthorizedParties []string
+}
+
+/*
+Verifier for OpenID Connect token(ID Token, or JWT access token).
+Verifier loads discovery document and keys, so create once and reuse it.
+*/
+type OidcVerifier interface {
+	// Set custom logger.
+	SetLogger(logger *Logger)
+
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
+	// Verify token.
+	// signature, iss, aud, exp, nbf and azp are validated.
+	Verify(ctx context.Context, token string) (*VerifiedOidcToken, error)
+}
@@ -0,0 +1,203 @@
+package secure_backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type oidcVerifierImpl struct {
+	owner *securityContextImpl
+
+	logger *slog.Logger
+
+	/*
+		Verifier name for metrics and audit.
+	*/
+	name string
+
+	configs OidcVerifierConfigs
+
+	/*
+		JWKS cache.
+	*/
+	keys *googlePublicKeyCache
+}
+
+func (it *securityContextImpl) NewOidcVerifier(ctx context.Context, configs *OidcVerifierConfigs) (OidcVerifier, error) {
+	return it.newOidcVerifier(ctx, configs, metricsVerifierOidc)
+}
+
+func (it *securityContextImpl) newOidcVerifier(ctx context.Context, configs *OidcVerifierConfigs, name string) (*oidcVerifierImpl, error) {
+	if configs == nil || len(configs.IssuerUrl) == 0 {
+		return nil, errors.New("OidcVerifierConfigs.IssuerUrl is empty")
+	} else if len(configs.Audiences) == 0 {
+		return nil, errors.New("OidcVerifierConfigs.Audiences is empty")
+	}
+
+	discovery, err := getOidcDiscovery(ctx, configs.IssuerUrl)
+	if err != nil {
+		return nil, newVerificationError(ErrBackendUnavailable, "OpenID Connect discovery failed", err)
+	}
+
+	keys := newJwksPublicKeyCache(discovery.JwksUri, it.logger, it.tracer, it.getMetrics())
+	keys.tokenTime = it.tokenTime
+	if err := keys.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	it.logger.Info("OpenID Connect provider loaded",
+		slog.String("issuer", discovery.Issuer),
+		slog.String("jwks_uri", discovery.JwksUri),
+		slog.Any("audiences", configs.Audiences))
+	return &oidcVerifierImpl{
+		owner:   it,
+		logger:  it.logger,
+		name:    name,
+		configs: *configs,
+		keys:    keys,
+	}, nil
+}
+
+func (it *oidcVerifierImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
+}
+
+func (it *oidcVerifierImpl) SetSlogLogger(logger *slog.Logger) {
+	it.logger = logger
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Validate claims, exp/nbf/iat are validated by parser.
+*/
+func (it *oidcVerifierImpl) validateClaims(claims jwt.MapClaims) error {
+	if !claims.VerifyIssuer(it.configs.IssuerUrl, true) {
+		return newVerificationError(ErrInvalidIssuer, "invalid JWT.iss", nil)
+	} else if _, ok := claims["exp"]; !ok {
+		return newVerificationError(ErrMalformedToken, "JWT.exp not found", nil)
+	} else if sub, _ := claims["sub"].(string); len(sub) == 0 {
+		return newVerificationError(ErrMalformedToken, "JWT.sub not found", nil)
+	}
+
+	validAudience := false
+	for _, audience := range it.configs.Audiences {
+		if claims.VerifyAudience(audience, true) {
+			validAudience = true
+			break
+		}
+	}
+	if !validAudience {
+		return newVerificationError(ErrInvalidAudience, "invalid JWT.aud", nil)
+	}
+
+	// OpenID Connect Core 1.0, 'azp' is required for multiple audiences.
+	azp, _ := claims["azp"].(string)
+	if len(it.configs.AuthorizedParties) > 0 {
+		if !containsString(it.configs.AuthorizedParties, azp) {
+			return newVerificationError(ErrInvalidAudience, fmt.Sprintf("invalid JWT.azp(%v)", azp), nil)
+		}
+	} else if getAudienceCount(claims) > 1 && !containsString(it.configs.Audiences, azp) {
+		return newVerificationError(ErrInvalidAudience, fmt.Sprintf("invalid JWT.azp(%v) for multiple audiences", azp), nil)
+	}
+	return nil
+}
+
+/*
+Returns number of 'aud' values.
+*/
+func getAudienceCount(claims jwt.MapClaims) int {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return 1
+	case []interface{}:
+		return len(aud)
+	case []string:
+		return len(aud)
+	}
+	return 0
+}
+
+func (it *oidcVerifierImpl) Verify(ctx context.Context, token string) (result *VerifiedOidcToken, err error) {
+	startAt := time.Now()
+	var kid, sub string
+	ctx, span := it.owner.startSpan(ctx, "OidcVerifier.Verify",
+		attribute.String("issuer", it.configs.IssuerUrl))
+	defer func() {
+		it.owner.getMetrics().ObserveVerification(it.name, getVerificationResult(err), time.Since(startAt))
+		it.owner.recentErrors.add(it.name, err)
+		endSpan(span, err)
+
+		outcome, reasonCode := getAuditOutcome(err)
+		it.owner.emitAudit(ctx, &AuditEvent{
+			Principal:   it.owner.redaction.identifier(sub),
+			Method:      "oidc",
+			Verifier:    it.name,
+			Outcome:     outcome,
+			ReasonCode:  reasonCode,
+			TokenIssuer: it.configs.IssuerUrl,
+			TokenKid:    kid,
+		})
+	}()
+
+	unverified, _, parseErr := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if parseErr != nil {
+		it.logger.Warn("token parse error", slog.String("outcome", "denied"), slog.Any("error", parseErr))
+		return nil, newVerificationError(ErrMalformedToken, "token parse error", parseErr)
+	}
+	kid, _ = unverified.Header["kid"].(string)
+	sub, _ = unverified.Claims.(jwt.MapClaims)["sub"].(string)
+
+	logger := it.logger.With(
+		slog.String("issuer", it.configs.IssuerUrl),
+		slog.String("kid", kid),
+		it.owner.redaction.subjectAttr(sub),
+	)
+	span.SetAttributes(attribute.String("kid", kid))
+
+	_, parsed, err := it.keys.parseJwt(ctx, token)
+	if err == nil {
+		err = it.validateClaims(parsed.Claims.(jwt.MapClaims))
+	}
+	if err != nil {
+		logger.Log(ctx, getVerificationErrorLogLevel(err), "token verify failed",
+			slog.Duration("duration", time.Since(startAt)),
+			slog.String("outcome", "denied"),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	claims := parsed.Claims.(jwt.MapClaims)
+	var expireAt time.Time
+	switch exp := claims["exp"].(type) {
+	case float64:
+		expireAt = time.Unix(int64(exp), 0)
+	case json.Number:
+		t, _ := exp.Int64()
+		expireAt = time.Unix(t, 0)
+	}
+
+	logger.Debug("token verified",
+		slog.Duration("duration", time.Since(startAt)),
+		slog.String("outcome", "allowed"))
+	return &VerifiedOidcToken{
+		Subject:  sub,
+		Issuer:   it.configs.IssuerUrl,
+		ExpireAt: expireAt,
+		Claims:   TokenClaims(claims),
+	}, nil
+}
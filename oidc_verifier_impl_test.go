@@ -0,0 +1,175 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eaglesakura/go-secure-backend/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOidcVerifierForTest(t *testing.T, configs *OidcVerifierConfigs) OidcVerifier {
+	owner := newSecurityContextForTest()
+	verifier, err := owner.NewOidcVerifier(context.Background(), configs)
+	assert.NoError(t, err)
+	return verifier
+}
+
+func TestOidcVerifierImpl_Verify(t *testing.T) {
+	ctx := context.Background()
+	issuer := testutils.NewFakeOidcIssuer()
+	defer issuer.Close()
+
+	verifier := newOidcVerifierForTest(t, &OidcVerifierConfigs{
+		IssuerUrl:         issuer.Url(),
+		Audiences:         []string{"api://example"},
+		AuthorizedParties: []string{"client-a"},
+	})
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		result := map[string]interface{}{
+			"iss":   issuer.Url(),
+			"sub":   "user-1",
+			"aud":   []string{"api://example", "other"},
+			"azp":   "client-a",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"email": "user@example.com",
+		}
+		for key, value := range overrides {
+			if value == nil {
+				delete(result, key)
+			} else {
+				result[key] = value
+			}
+		}
+		return result
+	}
+
+	verified, err := verifier.Verify(ctx, issuer.Sign(claims(nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", verified.GetUserId())
+	assert.Equal(t, issuer.Url(), verified.Issuer)
+	email, _ := verified.GetStringClaim("email")
+	assert.Equal(t, "user@example.com", email)
+	assert.NoError(t, Authorize(ctx, RequireClaimEquals("email", "user@example.com"), verified))
+
+	for name, test := range map[string]struct {
+		claims map[string]interface{}
+		kind   error
+	}{
+		"iss":     {claims(map[string]interface{}{"iss": "https://evil.example.com"}), ErrInvalidIssuer},
+		"aud":     {claims(map[string]interface{}{"aud": "other"}), ErrInvalidAudience},
+		"azp":     {claims(map[string]interface{}{"azp": "client-b"}), ErrInvalidAudience},
+		"no azp":  {claims(map[string]interface{}{"azp": nil}), ErrInvalidAudience},
+		"exp":     {claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), ErrTokenExpired},
+		"no exp":  {claims(map[string]interface{}{"exp": nil}), ErrMalformedToken},
+		"nbf":     {claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()}), ErrTokenNotValidYet},
+		"no sub":  {claims(map[string]interface{}{"sub": nil}), ErrMalformedToken},
+		"no sign": {nil, ErrMalformedToken},
+	} {
+		t.Run(name, func(t *testing.T) {
+			token := "broken.token"
+			if test.claims != nil {
+				token = issuer.Sign(test.claims)
+			}
+			_, err := verifier.Verify(ctx, token)
+			assert.True(t, errors.Is(err, test.kind), err)
+		})
+	}
+}
+
+func TestOidcVerifierImpl_Verify_multipleAudiences(t *testing.T) {
+	ctx := context.Background()
+	issuer := testutils.NewFakeOidcIssuer()
+	defer issuer.Close()
+
+	// without authorized parties.
+	verifier := newOidcVerifierForTest(t, &OidcVerifierConfigs{
+		IssuerUrl: issuer.Url(),
+		Audiences: []string{"api://example"},
+	})
+	sign := func(aud interface{}, azp string) string {
+		claims := map[string]interface{}{
+			"iss": issuer.Url(),
+			"sub": "user-1",
+			"aud": aud,
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"iat": time.Now().Unix(),
+		}
+		if len(azp) > 0 {
+			claims["azp"] = azp
+		}
+		return issuer.Sign(claims)
+	}
+
+	_, err := verifier.Verify(ctx, sign("api://example", ""))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, sign([]string{"api://example"}, ""))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, sign([]string{"api://example", "other"}, "api://example"))
+	assert.NoError(t, err)
+
+	// 'azp' is required for multiple audiences.
+	_, err = verifier.Verify(ctx, sign([]string{"api://example", "other"}, ""))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), err)
+	_, err = verifier.Verify(ctx, sign([]string{"api://example", "other"}, "other"))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), err)
+}
+
+func TestOidcVerifierImpl_Verify_rotate(t *testing.T) {
+	ctx := context.Background()
+	issuer := testutils.NewFakeOidcIssuer()
+	defer issuer.Close()
+
+	verifier := newOidcVerifierForTest(t, &OidcVerifierConfigs{
+		IssuerUrl: issuer.Url(),
+		Audiences: []string{"api://example"},
+	})
+
+	sign := func() string {
+		return issuer.Sign(map[string]interface{}{
+			"iss": issuer.Url(),
+			"sub": "user-1",
+			"aud": "api://example",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+	}
+
+	// unknown kid, just after initial refresh.
+	issuer.RotateKey()
+	_, err := verifier.Verify(ctx, sign())
+	assert.True(t, errors.Is(err, ErrUnknownKey), err)
+
+	// unknown kid, keys are refreshed.
+	keys := verifier.(*oidcVerifierImpl).keys
+	keys.lock.Lock()
+	keys.refreshStartedAt = time.Now().Add(-minPublicKeyRefreshInterval)
+	keys.lock.Unlock()
+	_, err = verifier.Verify(ctx, sign())
+	assert.NoError(t, err)
+
+	// rate limited.
+	issuer.RotateKey()
+	_, err = verifier.Verify(ctx, sign())
+	assert.True(t, errors.Is(err, ErrUnknownKey), err)
+}
+
+func TestSecurityContextImpl_NewOidcVerifier(t *testing.T) {
+	owner := newSecurityContextForTest()
+	issuer := testutils.NewFakeOidcIssuer()
+	defer issuer.Close()
+
+	// no audience
+	_, err := owner.NewOidcVerifier(context.Background(), &OidcVerifierConfigs{IssuerUrl: issuer.Url()})
+	assert.Error(t, err)
+
+	// issuer mismatch
+	_, err = owner.NewOidcVerifier(context.Background(), &OidcVerifierConfigs{
+		IssuerUrl: issuer.Url() + "/",
+		Audiences: []string{"api://example"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package secure_backend
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+Trusted issuer of original token.
+e.g.) peer service's account, which exchanges token for this service.
+*/
+type TrustedIssuer struct {
+	/*
+		Service account email of issuer('iss' and 'sub' claims).
+		e.g.) "orders@example.iam.gserviceaccount.com"
+	*/
+	ServiceAccount string
+
+	/*
+		Public key URL of issuer.
+		X.509 certificates if URL is ".../robot/v1/metadata/x509/...", otherwise JWKS.
+		default) https://www.googleapis.com/robot/v1/metadata/x509/<ServiceAccount>
+	*/
+	KeyUrl string
+
+	/*
+		Google Cloud project of issuer, set to VerifiedFirebaseAuthToken.ProjectId.
+		default) project of ServiceAccount, e.g.) "example" for "orders@example.iam.gserviceaccount.com"
+	*/
+	ProjectId string
+}
+
+/*
+Returns project of issuer, or empty if not found.
+*/
+func (it *TrustedIssuer) getProjectId() string {
+	if len(it.ProjectId) > 0 {
+		return it.ProjectId
+	}
+	const suffix = ".iam.gserviceaccount.com"
+	if index := strings.LastIndex(it.ServiceAccount, "@"); index >= 0 && strings.HasSuffix(it.ServiceAccount, suffix) {
+		return strings.TrimSuffix(it.ServiceAccount[index+1:], suffix)
+	}
+	return ""
+}
+
+/*
+Original token settings per verifier.
+
+e.g.)
+
+	err := verifier.SetOriginalTokenConfigs(&OriginalTokenConfigs{
+		ServiceUrl:             "https://orders.internal.example.com",
+		RequireServiceAudience: true,
+		TrustedIssuers: []*TrustedIssuer{
+			{ServiceAccount: "edge@example.iam.gserviceaccount.com"},
+		},
+	})
+*/
+type OriginalTokenConfigs struct {
+	/*
+		Accepted audiences.
+		If Audiences or ServiceUrl is set, then Firebase custom token audience is not accepted.
+	*/
+	Audiences []string
+
+	/*
+		Trusted issuers, in addition to own service account.
+		Audiences or ServiceUrl is required, Firebase custom token audience is never accepted from trusted issuers.
+	*/
+	TrustedIssuers []*TrustedIssuer
+
+	/*
+		URL of this service.
+		e.g.) "https://orders.internal.example.com"
+	*/
+	ServiceUrl string
+
+	/*
+		If true, then 'aud' should be ServiceUrl.
+		Audiences are not accepted.
+	*/
+	RequireServiceAudience bool
+}
+
+/*
+Returns public key URL of Google service account.
+*/
+func getServiceAccountKeyUrl(email string) string {
+	return "https://www.googleapis.com/robot/v1/metadata/x509/" + url.PathEscape(email)
+}
+
+func (it *OriginalTokenConfigs) validate() error {
+	if it.RequireServiceAudience && len(it.ServiceUrl) == 0 {
+		return errors.New("ServiceUrl is required for RequireServiceAudience")
+	}
+	if len(it.TrustedIssuers) > 0 && len(it.ServiceUrl) == 0 && len(it.Audiences) == 0 {
+		return errors.New("ServiceUrl or Audiences is required for TrustedIssuers")
+	}
+	for _, issuer := range it.TrustedIssuers {
+		if issuer == nil || !strings.Contains(issuer.ServiceAccount, "@") {
+			return fmt.Errorf("invalid trusted issuer: %v", issuer)
+		}
+		if len(issuer.KeyUrl) > 0 {
+			if _, err := url.ParseRequestURI(issuer.KeyUrl); err != nil {
+				return fmt.Errorf("invalid key URL(%v): %w", issuer.ServiceAccount, err)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Returns public keys of original token issuer.
+Own service account uses loaded keys, trusted issuers use lazy loaded keys.
+*/
+func (it *securityContextImpl) getOriginalTokenPublicKeys(issuer string, keyUrl string) *googlePublicKeyCache {
+	if issuer == it.gcp.clientEmail {
+		return it.gcp.serviceAccountPublicKeys
+	}
+	if len(keyUrl) == 0 {
+		keyUrl = getServiceAccountKeyUrl(issuer)
+	}
+
+	it.trustedIssuerLock.Lock()
+	defer it.trustedIssuerLock.Unlock()
+	if it.trustedIssuerKeys == nil {
+		it.trustedIssuerKeys = map[string]*googlePublicKeyCache{}
+	}
+	cacheKey := issuer + " " + keyUrl
+	if keyCache, ok := it.trustedIssuerKeys[cacheKey]; ok {
+		return keyCache
+	}
+	var keyCache *googlePublicKeyCache
+	if strings.Contains(keyUrl, "/robot/v1/metadata/x509/") {
+		keyCache = newGooglePublicKeyCache(keyUrl, it.logger, it.tracer, it.getMetrics())
+	} else {
+		keyCache = newJwksPublicKeyCache(keyUrl, it.logger, it.tracer, it.getMetrics())
+	}
+	keyCache.tokenTime = it.tokenTime
+	it.trustedIssuerKeys[cacheKey] = keyCache
+	return keyCache
+}
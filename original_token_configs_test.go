@@ -0,0 +1,136 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eaglesakura/go-secure-backend/testutils"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginalTokenConfigs_validate(t *testing.T) {
+	assert.NoError(t, (&OriginalTokenConfigs{}).validate())
+	assert.NoError(t, (&OriginalTokenConfigs{
+		ServiceUrl:             "https://orders.internal.example.com",
+		RequireServiceAudience: true,
+		TrustedIssuers: []*TrustedIssuer{
+			{ServiceAccount: "peer@example.iam.gserviceaccount.com"},
+			{ServiceAccount: "jwks@example.iam.gserviceaccount.com", KeyUrl: "https://example.com/jwks"},
+		},
+	}).validate())
+
+	assert.Error(t, (&OriginalTokenConfigs{RequireServiceAudience: true}).validate())
+	assert.Error(t, (&OriginalTokenConfigs{ServiceUrl: "https://orders.internal.example.com", TrustedIssuers: []*TrustedIssuer{{ServiceAccount: "peer"}}}).validate())
+	assert.Error(t, (&OriginalTokenConfigs{ServiceUrl: "https://orders.internal.example.com", TrustedIssuers: []*TrustedIssuer{nil}}).validate())
+	assert.Error(t, (&OriginalTokenConfigs{ServiceUrl: "https://orders.internal.example.com", TrustedIssuers: []*TrustedIssuer{
+		{ServiceAccount: "peer@example.iam.gserviceaccount.com", KeyUrl: "not url"},
+	}}).validate())
+	// audience is required for trusted issuers.
+	assert.Error(t, (&OriginalTokenConfigs{TrustedIssuers: []*TrustedIssuer{
+		{ServiceAccount: "peer@example.iam.gserviceaccount.com"},
+	}}).validate())
+}
+
+func TestTrustedIssuer_getProjectId(t *testing.T) {
+	assert.Equal(t, "example", (&TrustedIssuer{ServiceAccount: "orders@example.iam.gserviceaccount.com"}).getProjectId())
+	assert.Equal(t, "other", (&TrustedIssuer{ServiceAccount: "orders@example.iam.gserviceaccount.com", ProjectId: "other"}).getProjectId())
+	assert.Equal(t, "", (&TrustedIssuer{ServiceAccount: "orders@example.com"}).getProjectId())
+}
+
+func TestFirebaseAuthVerifierImpl_SetOriginalTokenConfigs(t *testing.T) {
+	ctx := context.Background()
+	owner := newOriginalTokenOwnerForTest(t)
+	peer := testutils.NewFakeOidcIssuer()
+	defer peer.Close()
+	const peerEmail = "peer@partner.iam.gserviceaccount.com"
+	const serviceUrl = "https://orders.internal.example.com"
+
+	verifier := owner.NewFirebaseAuthVerifier()
+	assert.NoError(t, verifier.SetOriginalTokenConfigs(&OriginalTokenConfigs{
+		ServiceUrl:             serviceUrl,
+		RequireServiceAudience: true,
+		TrustedIssuers: []*TrustedIssuer{
+			{ServiceAccount: peerEmail, KeyUrl: peer.Url() + "/jwks"},
+		},
+	}))
+
+	ownToken := func(aud string) string {
+		token, err := owner.gcp.tokenSigner.sign(ctx, jwt.MapClaims{
+			"iss": owner.gcp.clientEmail,
+			"sub": owner.gcp.clientEmail,
+			"aud": aud,
+			"uid": "user-1",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		assert.NoError(t, err)
+		return token
+	}
+	peerTokenWithAudience := func(iss string, aud string) string {
+		return peer.Sign(map[string]interface{}{
+			"iss": iss,
+			"sub": peerEmail,
+			"aud": aud,
+			"uid": "user-2",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	peerToken := func(iss string) string {
+		return peerTokenWithAudience(iss, serviceUrl)
+	}
+
+	// own service account
+	verified, err := verifier.Verify(ctx, ownToken(serviceUrl))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", verified.User.Id)
+	assert.Equal(t, owner.gcp.projectId, verified.ProjectId)
+
+	// audience should be service URL.
+	_, err = verifier.Verify(ctx, ownToken(firebaseCustomTokenAudience))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), err)
+
+	// trusted issuer, by own key URL.
+	verified, err = verifier.Verify(ctx, peerToken(peerEmail))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", verified.User.Id)
+	assert.Equal(t, "partner", verified.ProjectId)
+
+	_, err = verifier.Verify(ctx, peerToken(owner.gcp.clientEmail))
+	assert.True(t, errors.Is(err, ErrInvalidIssuer), err)
+
+	// default audience is accepted by other verifier.
+	other := owner.NewFirebaseAuthVerifier()
+	other.AcceptOriginalToken()
+	_, err = other.Verify(ctx, ownToken(firebaseCustomTokenAudience))
+	assert.NoError(t, err)
+
+	// configured audiences only.
+	audiences := owner.NewFirebaseAuthVerifier()
+	assert.NoError(t, audiences.SetOriginalTokenConfigs(&OriginalTokenConfigs{
+		ServiceUrl: serviceUrl,
+		TrustedIssuers: []*TrustedIssuer{
+			{ServiceAccount: peerEmail, KeyUrl: peer.Url() + "/jwks", ProjectId: "peer-project"},
+		},
+	}))
+	_, err = audiences.Verify(ctx, ownToken(serviceUrl))
+	assert.NoError(t, err)
+	verified, err = audiences.Verify(ctx, peerToken(peerEmail))
+	assert.NoError(t, err)
+	assert.Equal(t, "peer-project", verified.ProjectId)
+	_, err = audiences.Verify(ctx, ownToken(firebaseCustomTokenAudience))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), err)
+	_, err = audiences.Verify(ctx, peerTokenWithAudience(peerEmail, firebaseCustomTokenAudience))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), err)
+
+	// key cache is shared by verifiers.
+	assert.Equal(t,
+		owner.getOriginalTokenPublicKeys(peerEmail, peer.Url()+"/jwks"),
+		owner.getOriginalTokenPublicKeys(peerEmail, peer.Url()+"/jwks"))
+	assert.Equal(t, owner.gcp.serviceAccountPublicKeys, owner.getOriginalTokenPublicKeys(owner.gcp.clientEmail, ""))
+
+	assert.Error(t, verifier.SetOriginalTokenConfigs(&OriginalTokenConfigs{RequireServiceAudience: true}))
+}
@@ -0,0 +1,50 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/rsa"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+/*
+Signer for original token(signed by service account).
+*/
+type originalTokenSigner interface {
+	// Returns signed JWT.
+	// Signer may return cached token for same claims except 'iat', 'nbf' and 'exp',
+	// then cached token expires before 'exp', and has most of requested lifetime.
+	sign(ctx context.Context, claims jwt.MapClaims) (string, error)
+}
+
+/*
+Signer by service account private key, on memory.
+*/
+type privateKeyTokenSigner struct {
+	kid        string
+	privateKey *rsa.PrivateKey
+}
+
+func (it *privateKeyTokenSigner) sign(ctx context.Context, claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = it.kid
+	return token.SignedString(it.privateKey)
+}
+
+/*
+Returns 'exp' of signed token, without signature validation.
+*/
+func getSignedTokenExpireAt(token string) (time.Time, error) {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, newJwtVerificationError(err)
+	}
+	exp, ok, err := getTimeClaim(parsed.Claims.(jwt.MapClaims), "exp")
+	if err != nil {
+		return time.Time{}, err
+	} else if !ok {
+		return time.Time{}, newVerificationError(ErrMalformedToken, "invalid JWT.exp", nil)
+	}
+	return exp, nil
+}
@@ -0,0 +1,135 @@
+package secure_backend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+)
+
+/*
+Redaction mode for log(and audit) output.
+*/
+type RedactionMode int
+
+const (
+	/*
+		sha512 hex digest.
+		default.
+	*/
+	RedactionHash RedactionMode = iota
+
+	/*
+		HMAC-SHA256 pseudonym by RedactionPolicy.HmacSecret.
+		Same value is same pseudonym, so values can be correlated between services which share secret.
+	*/
+	RedactionHmac
+
+	/*
+		First RedactionPolicy.TruncateLength characters.
+		e.g.) "AIzaSyD..." -> "AIza..."
+	*/
+	RedactionTruncate
+
+	/*
+		Plain text.
+	*/
+	RedactionNone
+)
+
+/*
+Redaction policy for all log(and audit) output of this library.
+Zero value(or nil) is hash for all values, so secrets are never emitted in plain text by default.
+*/
+type RedactionPolicy struct {
+	/*
+		For identifiers.
+		e.g.) Firebase uid, client id
+	*/
+	Identifier RedactionMode
+
+	/*
+		For secrets.
+		e.g.) API Key
+	*/
+	Secret RedactionMode
+
+	/*
+		Secret for RedactionHmac.
+	*/
+	HmacSecret []byte
+
+	/*
+		Length for RedactionTruncate.
+		default) 4
+	*/
+	TruncateLength int
+}
+
+func (it *RedactionPolicy) validate() error {
+	if it == nil {
+		return nil
+	}
+	if (it.Identifier == RedactionHmac || it.Secret == RedactionHmac) && len(it.HmacSecret) == 0 {
+		return errors.New("RedactionPolicy.HmacSecret is empty")
+	}
+	return nil
+}
+
+func (it *RedactionPolicy) redact(mode RedactionMode, value string) string {
+	switch mode {
+	case RedactionNone:
+		return value
+	case RedactionHmac:
+		mac := hmac.New(sha256.New, it.HmacSecret)
+		_, _ = mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	case RedactionTruncate:
+		length := it.TruncateLength
+		if length <= 0 {
+			length = 4
+		}
+		if len(value) <= length {
+			// short value is not truncated, so do not expose it.
+			return "..."
+		}
+		return value[:length] + "..."
+	default:
+		return sha512sum(value)
+	}
+}
+
+/*
+Returns redacted identifier.
+*/
+func (it *RedactionPolicy) identifier(value string) string {
+	if it == nil {
+		return sha512sum(value)
+	}
+	return it.redact(it.Identifier, value)
+}
+
+/*
+Returns redacted secret.
+*/
+func (it *RedactionPolicy) secret(value string) string {
+	if it == nil {
+		return sha512sum(value)
+	}
+	return it.redact(it.Secret, value)
+}
+
+/*
+Returns redacted subject(uid, client id) log attribute.
+*/
+func (it *RedactionPolicy) subjectAttr(subject string) slog.Attr {
+	return slog.String("sub", it.identifier(subject))
+}
+
+/*
+Returns redacted API Key log attribute.
+*/
+func (it *RedactionPolicy) apiKeyAttr(apiKey string) slog.Attr {
+	return slog.String("api_key", it.secret(apiKey))
+}
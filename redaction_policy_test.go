@@ -0,0 +1,40 @@
+package secure_backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactionPolicy(t *testing.T) {
+	apiKey := "AIzaSyExample"
+
+	// default, never plain text.
+	var policy *RedactionPolicy
+	assert.Equal(t, sha512sum(apiKey), policy.secret(apiKey))
+	assert.Equal(t, sha512sum("uid"), policy.identifier("uid"))
+	assert.Equal(t, sha512sum(apiKey), (&RedactionPolicy{}).secret(apiKey))
+
+	policy = &RedactionPolicy{
+		Identifier:     RedactionNone,
+		Secret:         RedactionTruncate,
+		TruncateLength: 4,
+	}
+	assert.Equal(t, "uid", policy.identifier("uid"))
+	assert.Equal(t, "AIza...", policy.secret(apiKey))
+	assert.Equal(t, "...", policy.secret("key"))
+	assert.Equal(t, "api_key", policy.apiKeyAttr(apiKey).Key)
+	assert.Equal(t, "sub", policy.subjectAttr("uid").Key)
+
+	// correlated pseudonym
+	policy = &RedactionPolicy{
+		Identifier: RedactionHmac,
+		HmacSecret: []byte("secret"),
+	}
+	assert.NoError(t, policy.validate())
+	assert.Equal(t, policy.identifier("uid"), policy.identifier("uid"))
+	assert.NotEqual(t, policy.identifier("uid"), policy.identifier("other"))
+	assert.NotEqual(t, (&RedactionPolicy{Identifier: RedactionHmac, HmacSecret: []byte("other")}).identifier("uid"), policy.identifier("uid"))
+
+	assert.Error(t, (&RedactionPolicy{Secret: RedactionHmac}).validate())
+}
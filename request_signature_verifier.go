@@ -0,0 +1,33 @@
+package secure_backend
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+/*
+HMAC request signature verifier.
+for server-to-server request.
+
+Signature is HMAC-SHA256 over canonical request, see SignRequest().
+*/
+type RequestSignatureVerifier interface {
+	// Set custom logger.
+	SetLogger(logger *Logger)
+
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
+	// Set allowed clock skew between client and server.
+	// Default is 5 minutes.
+	SetClockSkew(skew time.Duration)
+
+	// Set custom nonce store.
+	// Default is in-memory store, see NewMemoryNonceStore().
+	SetNonceStore(store NonceStore)
+
+	// Verify signed request.
+	// Nonce is recorded after signature verified, and reused nonce is rejected(ErrReplayedRequest).
+	Verify(ctx context.Context, request *SignedRequest) (*VerifiedClient, error)
+}
@@ -0,0 +1,109 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type requestSignatureVerifierImpl struct {
+	owner *securityContextImpl
+
+	logger *slog.Logger
+
+	secretStore ClientSecretStore
+
+	nonceStore NonceStore
+
+	/*
+		option.
+	*/
+	clockSkew time.Duration
+}
+
+func (it *requestSignatureVerifierImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
+}
+
+func (it *requestSignatureVerifierImpl) SetSlogLogger(logger *slog.Logger) {
+	it.logger = logger
+}
+
+func (it *requestSignatureVerifierImpl) SetClockSkew(skew time.Duration) {
+	it.clockSkew = skew
+}
+
+func (it *requestSignatureVerifierImpl) SetNonceStore(store NonceStore) {
+	it.nonceStore = store
+}
+
+func (it *requestSignatureVerifierImpl) Verify(ctx context.Context, request *SignedRequest) (result *VerifiedClient, err error) {
+	startAt := time.Now()
+	defer func() {
+		it.owner.getMetrics().ObserveVerification(metricsVerifierRequestSignature, getVerificationResult(err), time.Since(startAt))
+
+		it.owner.recentErrors.add(metricsVerifierRequestSignature, err)
+
+		outcome, reasonCode := getAuditOutcome(err)
+		it.owner.emitAudit(ctx, &AuditEvent{
+			Principal:  it.owner.redaction.identifier(request.ClientId),
+			Method:     "hmac",
+			Verifier:   metricsVerifierRequestSignature,
+			Outcome:    outcome,
+			ReasonCode: reasonCode,
+		})
+	}()
+
+	if err := request.validate(); err != nil {
+		return nil, newVerificationError(ErrMalformedToken, "invalid signed request", err)
+	}
+
+	secret, err := it.secretStore.GetClientSecret(ctx, request.ClientId)
+	if err != nil {
+		return nil, newVerificationError(ErrBackendUnavailable, "client secret load failed", err)
+	} else if len(secret) == 0 {
+		it.logger.Warn("unknown client", it.owner.redaction.subjectAttr(request.ClientId), slog.String("outcome", "denied"))
+		return nil, newVerificationError(ErrUnknownKey, "unknown client", nil)
+	}
+
+	signature, err := hex.DecodeString(request.Signature)
+	if err != nil {
+		return nil, newVerificationError(ErrMalformedToken, "invalid signature encoding", err)
+	}
+	expected, _ := hex.DecodeString(SignRequest(secret, request))
+	if !hmac.Equal(signature, expected) {
+		it.logger.Warn("invalid signature", it.owner.redaction.subjectAttr(request.ClientId), slog.String("outcome", "denied"))
+		return nil, newVerificationError(ErrInvalidSignature, "invalid request signature", nil)
+	}
+
+	now := it.owner.now()
+	if request.Timestamp.Before(now.Add(-it.clockSkew)) {
+		return nil, newVerificationError(ErrTokenExpired, fmt.Sprintf("signed request expired(%v)", request.Timestamp), nil)
+	} else if request.Timestamp.After(now.Add(it.clockSkew)) {
+		return nil, newVerificationError(ErrTokenNotValidYet, fmt.Sprintf("signed request timestamp is future(%v)", request.Timestamp), nil)
+	}
+
+	// Nonce is valid until signed request expire.
+	// Timestamp is 'now + clockSkew' at most, so nonce is clamped to 'now + 2 * clockSkew' by server clock.
+	expireAt := request.Timestamp.Add(it.clockSkew)
+	if maxExpireAt := now.Add(2 * it.clockSkew); expireAt.After(maxExpireAt) {
+		expireAt = maxExpireAt
+	}
+	added, err := it.nonceStore.Add(ctx, request.ClientId+":"+request.Nonce, expireAt)
+	if err != nil {
+		return nil, err
+	} else if !added {
+		it.logger.Warn("replayed request", it.owner.redaction.subjectAttr(request.ClientId), slog.String("outcome", "denied"))
+		return nil, newVerificationError(ErrReplayedRequest, "nonce already used", nil)
+	}
+
+	it.logger.Debug("signed request verified", it.owner.redaction.subjectAttr(request.ClientId), slog.String("outcome", "allowed"))
+	return &VerifiedClient{
+		ClientId: request.ClientId,
+		SignedAt: request.Timestamp,
+		Nonce:    request.Nonce,
+	}, nil
+}
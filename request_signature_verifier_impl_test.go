@@ -0,0 +1,180 @@
+package secure_backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedRequestForTest(secret []byte, timestamp time.Time, nonce string) *SignedRequest {
+	request := &SignedRequest{
+		ClientId:  "partner",
+		Method:    "POST",
+		Path:      "/api/orders",
+		Query:     "limit=10&page=2",
+		Body:      []byte(`{"id":1}`),
+		Timestamp: timestamp,
+		Nonce:     nonce,
+	}
+	request.Signature = SignRequest(secret, request)
+	return request
+}
+
+func TestRequestSignatureVerifierImpl_Verify(t *testing.T) {
+	ctx := context.Background()
+	owner := newSecurityContextForTest()
+	owner.nonceStore = NewMemoryNonceStore(10)
+	secret := []byte("secret")
+	verifier := owner.NewRequestSignatureVerifier(StaticClientSecretStore{
+		"partner": secret,
+	})
+
+	request := newSignedRequestForTest(secret, time.Now(), "nonce-1")
+	verified, err := verifier.Verify(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, "partner", verified.ClientId)
+
+	// replay, client id is not in error message.
+	_, err = verifier.Verify(ctx, request)
+	assert.True(t, errors.Is(err, ErrReplayedRequest))
+	assert.NotContains(t, err.Error(), "partner")
+
+	// replay, other verifier instance
+	_, err = owner.NewRequestSignatureVerifier(StaticClientSecretStore{"partner": secret}).Verify(ctx, request)
+	assert.True(t, errors.Is(err, ErrReplayedRequest))
+
+	// broken body
+	request = newSignedRequestForTest(secret, time.Now(), "nonce-2")
+	request.Body = []byte(`{"id":2}`)
+	_, err = verifier.Verify(ctx, request)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	// broken query
+	request = newSignedRequestForTest(secret, time.Now(), "nonce-7")
+	request.Query = "limit=1000&page=2"
+	_, err = verifier.Verify(ctx, request)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	// clock skew
+	_, err = verifier.Verify(ctx, newSignedRequestForTest(secret, time.Now().Add(-time.Hour), "nonce-3"))
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+	_, err = verifier.Verify(ctx, newSignedRequestForTest(secret, time.Now().Add(time.Hour), "nonce-4"))
+	assert.True(t, errors.Is(err, ErrTokenNotValidYet))
+
+	// other secret
+	_, err = verifier.Verify(ctx, newSignedRequestForTest([]byte("other"), time.Now(), "nonce-5"))
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	// unknown client
+	request = newSignedRequestForTest(secret, time.Now(), "nonce-6")
+	request.ClientId = "stranger"
+	_, err = verifier.Verify(ctx, request)
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+	assert.NotContains(t, err.Error(), "stranger")
+}
+
+func TestNewSignedRequest(t *testing.T) {
+	secret := []byte("secret")
+	timestamp := time.Unix(1700000000, 0)
+	expected := newSignedRequestForTest(secret, timestamp, "nonce")
+
+	r := httptest.NewRequest("POST", "https://example.com/api/orders?page=2&limit=10", bytes.NewReader(expected.Body))
+	r.Header.Set(SignedRequestClientIdHeader, "partner")
+	r.Header.Set(SignedRequestTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	r.Header.Set(SignedRequestNonceHeader, "nonce")
+	r.Header.Set(SignedRequestSignatureHeader, expected.Signature)
+
+	request, err := NewSignedRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, request)
+
+	// query order is not signed.
+	expected.Query = "page=2&limit=10"
+	assert.Equal(t, request.Signature, SignRequest(secret, expected))
+
+	// too large body
+	r = httptest.NewRequest("POST", "https://example.com/api/orders", bytes.NewReader(make([]byte, maxSignedRequestBodySize+1)))
+	r.Header.Set(SignedRequestTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	_, err = NewSignedRequest(r)
+	assert.True(t, errors.Is(err, ErrMalformedToken), err)
+}
+
+func TestMemoryNonceStore_Add(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryNonceStore(2)
+
+	added, err := store.Add(ctx, "a", time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.Add(ctx, "b", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	// "a" is expired, removed.
+	added, err = store.Add(ctx, "c", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = store.Add(ctx, "b", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	// full
+	_, err = store.Add(ctx, "d", time.Now().Add(time.Hour))
+	assert.True(t, errors.Is(err, ErrBackendUnavailable))
+}
+
+func TestMemoryNonceStore_Add_unordered(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := newMemoryNonceStore(2, func() time.Time {
+		return now
+	})
+
+	// far-future nonce does not block eviction of expired nonces.
+	added, err := store.Add(ctx, "future", now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.Add(ctx, "a", now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	now = now.Add(time.Minute)
+	added, err = store.Add(ctx, "b", now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.Add(ctx, "future", now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, added)
+}
+
+func TestRequestSignatureVerifierImpl_Verify_clock(t *testing.T) {
+	ctx := context.Background()
+	owner := newSecurityContextForTest()
+	clock := &fixedClock{now: time.Now().Add(-24 * time.Hour)}
+	owner.tokenTime = &tokenTimePolicy{clock: clock}
+	owner.nonceStore = newMemoryNonceStore(10, owner.now)
+	sink := NewMemoryAuditSink()
+	owner.audit = newAuditDispatcher([]AuditSink{sink}, 10, nil, owner.logger)
+	secret := []byte("secret")
+	verifier := owner.NewRequestSignatureVerifier(StaticClientSecretStore{
+		"partner": secret,
+	})
+
+	// nonce expiration is checked by configured clock, behind system clock.
+	request := newSignedRequestForTest(secret, clock.now, "nonce-1")
+	_, err := verifier.Verify(ctx, request)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, request)
+	assert.True(t, errors.Is(err, ErrReplayedRequest), err)
+
+	// audit event time is also configured clock.
+	assert.NoError(t, owner.audit.close(ctx))
+	assert.Equal(t, clock.now, sink.Events()[0].Time)
+}
@@ -1,5 +1,11 @@
 package secure_backend
 
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
 type SecurityContext interface {
 	// Returns Firebase auth based JWT verifier.
 	//
@@ -12,4 +18,48 @@ type SecurityContext interface {
 	// see)
 	// 	- https://cloud.google.com/docs/authentication/api-keys?hl=en
 	NewGoogleApiKeyVerifier() GoogleApiKeyVerifier
+
+	// Returns HMAC request signature verifier.
+	// Client secrets are loaded from store.
+	NewRequestSignatureVerifier(store ClientSecretStore) RequestSignatureVerifier
+
+	// Returns OpenID Connect token verifier.
+	// Discovery document and keys are loaded from issuer.
+	// see)
+	// 	- https://openid.net/specs/openid-connect-core-1_0.html
+	NewOidcVerifier(ctx context.Context, configs *OidcVerifierConfigs) (OidcVerifier, error)
+
+	// Returns GitHub Actions OIDC token verifier.
+	// audience is requested by workflow, e.g.) "https://api.example.com"
+	NewGithubActionsVerifier(ctx context.Context, audience string) (GithubActionsVerifier, error)
+
+	// Returns token exchanger, verified token to down-scoped original token.
+	// Service account private key is required.
+	// see)
+	// 	- https://www.rfc-editor.org/rfc/rfc8693
+	NewTokenExchanger() TokenExchanger
+
+	// Returns cached Firebase user lookup, by Admin API.
+	// If ttl is zero, then 5 minutes.
+	NewFirebaseUserLookup(ttl time.Duration) FirebaseUserLookup
+
+	// Returns Firebase Auth custom claims manager.
+	// see)
+	// 	- https://firebase.google.com/docs/auth/admin/custom-claims?hl=en
+	NewCustomClaimsManager() CustomClaimsManager
+
+	// Returns count of audit events dropped by full queue.
+	DroppedAuditEvents() uint64
+
+	// Returns health of public keys and backend APIs.
+	// see) NewHealthHandler()
+	Health() *HealthReport
+
+	// Returns debug handler, renders public keys, project, cache sizes and recent errors as JSON.
+	// Secrets are never rendered, but handler should be protected.
+	// e.g.) NewFirebaseAuthMiddleware(verifier, RequireAdmin())(securityContext.NewDebugHandler())
+	NewDebugHandler() http.Handler
+
+	// Stop background refresher, and flush audit events.
+	Close(ctx context.Context) error
 }
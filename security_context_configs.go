@@ -1,14 +1,88 @@
 package secure_backend
 
+import (
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
 /*
 logger function
 */
 type SecurityContextConfigs struct {
 	/*
 		Custom logger.
+		Structured log record is formatted to "message key=value ...".
 	*/
 	Logger *Logger
 
+	/*
+		Custom structured logger.
+		Priority: SlogLogger > SlogHandler > Logger.
+	*/
+	SlogLogger *slog.Logger
+
+	/*
+		Custom structured log handler.
+	*/
+	SlogHandler slog.Handler
+
+	/*
+		OpenTelemetry tracer provider.
+		If this value is nil, then tracing is disabled.
+	*/
+	TracerProvider trace.TracerProvider
+
+	/*
+		Metrics reporter.
+		If this value is nil, then metrics are disabled.
+
+		see) NewPrometheusMetrics(), NewOpenTelemetryMetrics()
+	*/
+	Metrics Metrics
+
+	/*
+		Redaction policy for log and audit output.
+		If this value is nil, then identifiers and secrets are hashed.
+	*/
+	Redaction *RedactionPolicy
+
+	/*
+		Public key refresh interval in background.
+		If this value is zero, then keys are refreshed only when unknown key found.
+		Background refresher is stopped by SecurityContext.Close().
+	*/
+	KeyRefreshInterval time.Duration
+
+	/*
+		Thresholds for SecurityContext.Health().
+		If this value is nil, then default thresholds are used.
+	*/
+	HealthThresholds *HealthThresholds
+
+	/*
+		Clock for token expiry and age checks.
+		If this value is nil, then system clock is used.
+		Firebase ID tokens are checked by Firebase Admin SDK with system clock,
+		so this clock is not used for them.
+	*/
+	Clock Clock
+
+	/*
+		Allowed clock skew for 'exp', 'nbf' and 'iat' claims.
+		Firebase ID tokens use fixed clock skew of Firebase Admin SDK instead.
+		default) 0
+	*/
+	TokenLeeway time.Duration
+
+	/*
+		Maximum token lifetime('exp' - 'iat').
+		If this value is zero, then not checked.
+		e.g.) time.Hour
+	*/
+	MaxTokenLifetime time.Duration
+
 	/*
 		Custom GCP service account's json file.
 		If this value is nil, then load from 'GOOGLE_APPLICATION_CREDENTIALS'.
@@ -16,4 +90,54 @@ type SecurityContextConfigs struct {
 		see) https://cloud.google.com/docs/authentication/getting-started?hl=en
 	*/
 	GoogleServiceAccountJson []byte
+
+	/*
+		Local API Key file path.
+		If this value is not empty, then NewGoogleApiKeyVerifier() returns verifier by this file,
+		and ServiceControl API is not used.
+		for non-GCP(on-premises) deployment.
+
+		see) HashLocalApiKey()
+	*/
+	LocalApiKeyFile string
+
+	/*
+		Accepted Firebase Project IDs.
+		If this value is empty, then only service account's project is accepted.
+
+		Service account should be able to access all projects,
+		and token is verified by project of 'aud' claim.
+	*/
+	FirebaseProjectIds []string
+
+	/*
+		Audit event sinks.
+		If this value is empty, then audit is disabled.
+
+		see) NewJsonAuditSink(), NewRotatingFileAuditSink(), NewMemoryAuditSink()
+	*/
+	AuditSinks []AuditSink
+
+	/*
+		Audit event queue size.
+		If queue is full, then event is dropped.
+		default) 1024
+	*/
+	AuditBufferSize int
+
+	/*
+		HMAC-SHA256 key of audit event chain, see VerifyAuditEventChainHmac().
+		If this value is empty, then events are chained by sha256, and anyone can rebuild the chain.
+		e.g.) 32 bytes secret from Secret Manager.
+	*/
+	AuditHmacKey []byte
+
+	/*
+		Audit event chain head store.
+		Chain continues from stored head after restart.
+		If this value is nil, then chain starts from empty per process.
+
+		see) NewFileAuditChainHeadStore()
+	*/
+	AuditChainHeadStore AuditChainHeadStore
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/url"
+	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/compute/metadata"
@@ -14,17 +16,115 @@ import (
 	"firebase.google.com/go/auth"
 	"github.com/golang-jwt/jwt"
 	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 	"google.golang.org/api/servicecontrol/v1"
 )
 
+/*
+Firebase and Google APIs are not available, see securityContextImpl.gcp.disabled.
+*/
+var errGcpDisabled = newVerificationError(ErrBackendUnavailable, "Google Cloud Platform is not initialized", nil)
+
 type securityContextImpl struct {
-	logger *Logger
+	logger *slog.Logger
+
+	/*
+		OpenTelemetry tracer.
+	*/
+	tracer trace.Tracer
+
+	/*
+		Metrics reporter.
+	*/
+	metrics Metrics
+
+	/*
+		Redaction policy for log(and audit) output.
+		nil is default policy.
+	*/
+	redaction *RedactionPolicy
+
+	/*
+		Local API Key file path.
+	*/
+	localApiKeyFile string
+
+	/*
+		Local API Keys, or nil.
+	*/
+	localApiKeys *localApiKeyStore
+
+	/*
+		Used nonces of signed request.
+		shared by all RequestSignatureVerifier.
+	*/
+	nonceStore NonceStore
+
+	/*
+		Audit event sinks.
+	*/
+	auditSinks []AuditSink
+
+	/*
+		Audit event queue size.
+	*/
+	auditBufferSize int
+
+	/*
+		Audit event chain HMAC key, or empty.
+	*/
+	auditHmacKey []byte
+
+	/*
+		Audit event chain head store, or nil.
+	*/
+	auditChainHeadStore AuditChainHeadStore
+
+	/*
+		Audit event dispatcher, or nil.
+	*/
+	audit *auditDispatcher
+
+	/*
+		Public key refresh interval.
+	*/
+	keyRefreshInterval time.Duration
+
+	healthThresholds HealthThresholds
+
+	/*
+		Public keys of trusted original token issuers, key is "<email> <key URL>".
+	*/
+	trustedIssuerKeys map[string]*googlePublicKeyCache
+	trustedIssuerLock sync.Mutex
+
+	/*
+		Clock, leeway and lifetime limit for token validation.
+	*/
+	tokenTime *tokenTimePolicy
+
+	/*
+		Last call result of backend APIs, for health.
+	*/
+	serviceControlStatus backendStatus
+	firebaseStatus       backendStatus
+
+	/*
+		Recent verification errors, for debug handler.
+	*/
+	recentErrors recentErrorBuffer
 
 	/*
 		Google Cloud Platform data.
 	*/
 	gcp struct {
+		/*
+			If true, then GCP is not initialized.
+			LocalApiKeyFile mode without GCP credentials(on-premises).
+		*/
+		disabled bool
+
 		/*
 			Validated API Keys on memory.
 		*/
@@ -42,6 +142,11 @@ type securityContextImpl struct {
 		*/
 		clientEmail string
 
+		/*
+			Signer for original token, or nil.
+		*/
+		tokenSigner originalTokenSigner
+
 		/*
 			GCP Project ID
 		*/
@@ -52,6 +157,16 @@ type securityContextImpl struct {
 		*/
 		firebaseAuth *auth.Client
 
+		/*
+			Accepted Firebase Project IDs.
+		*/
+		firebaseProjectIds []string
+
+		/*
+			Firebase Auth API Client per accepted project, or nil.
+		*/
+		firebaseAuthByProject map[string]*auth.Client
+
 		/*
 			Google ServiceControl API client.
 		*/
@@ -59,10 +174,6 @@ type securityContextImpl struct {
 	}
 }
 
-func (it *securityContextImpl) logInfo(message string) {
-	it.logger.logInfo(message)
-}
-
 func (it *securityContextImpl) NewFirebaseAuthVerifier() FirebaseAuthVerifier {
 	return &firebaseAuthVerifierImpl{
 		owner:  it,
@@ -71,13 +182,67 @@ func (it *securityContextImpl) NewFirebaseAuthVerifier() FirebaseAuthVerifier {
 }
 
 func (it *securityContextImpl) NewGoogleApiKeyVerifier() GoogleApiKeyVerifier {
+	if it.localApiKeys != nil {
+		return &localApiKeyVerifierImpl{
+			owner:  it,
+			logger: it.logger,
+		}
+	}
 	return &googleApiKeyVerifierImpl{
 		owner:  it,
 		logger: it.logger,
 	}
 }
 
-func (it *securityContextImpl) getGoogleProjectInfoFromJson(file []byte) (projectId string, email string, publicKey *googlePublicKey, err error) {
+func (it *securityContextImpl) NewRequestSignatureVerifier(store ClientSecretStore) RequestSignatureVerifier {
+	return &requestSignatureVerifierImpl{
+		owner:       it,
+		logger:      it.logger,
+		secretStore: store,
+		nonceStore:  it.nonceStore,
+		clockSkew:   5 * time.Minute,
+	}
+}
+
+func (it *securityContextImpl) NewTokenExchanger() TokenExchanger {
+	return &tokenExchangerImpl{
+		owner:  it,
+		logger: it.logger,
+	}
+}
+
+func (it *securityContextImpl) NewCustomClaimsManager() CustomClaimsManager {
+	return &customClaimsManagerImpl{
+		getClient: it.getFirebaseUserClientByProject,
+		logger:    it.logger,
+		redaction: it.redaction,
+	}
+}
+
+func (it *securityContextImpl) DroppedAuditEvents() uint64 {
+	if it.audit == nil {
+		return 0
+	}
+	return it.audit.droppedEvents()
+}
+
+/*
+Returns Firebase Auth client for project of token.
+If accepted projects are not configured, then default client.
+*/
+func (it *securityContextImpl) getFirebaseAuth(projectId string) (*auth.Client, error) {
+	if it.gcp.firebaseAuth == nil {
+		return nil, errGcpDisabled
+	} else if it.gcp.firebaseAuthByProject == nil {
+		return it.gcp.firebaseAuth, nil
+	}
+	if client, ok := it.gcp.firebaseAuthByProject[projectId]; ok {
+		return client, nil
+	}
+	return nil, newVerificationError(ErrInvalidAudience, fmt.Sprintf("Firebase project is not accepted(%v)", projectId), nil)
+}
+
+func (it *securityContextImpl) getGoogleProjectInfoFromJson(file []byte) (projectId string, email string, publicKey *googlePublicKey, signer originalTokenSigner, err error) {
 	type ServiceAccountModel struct {
 		ProjectId    string `json:"project_id"`
 		ClientEmail  string `json:"client_email,omitempty"`
@@ -87,12 +252,12 @@ func (it *securityContextImpl) getGoogleProjectInfoFromJson(file []byte) (projec
 
 	dto := ServiceAccountModel{}
 	if err := json.Unmarshal(file, &dto); err != nil {
-		return "", "", nil, fmt.Errorf("service account parse error %w", err)
+		return "", "", nil, nil, fmt.Errorf("service account parse error %w", err)
 	}
 
 	var privateKey *rsa.PrivateKey
 	if pem, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(dto.PrivateKey)); err != nil {
-		return "", "", nil, fmt.Errorf("private key parse error %w", err)
+		return "", "", nil, nil, fmt.Errorf("private key parse error %w", err)
 	} else {
 		privateKey = pem
 	}
@@ -100,6 +265,9 @@ func (it *securityContextImpl) getGoogleProjectInfoFromJson(file []byte) (projec
 	return dto.ProjectId, dto.ClientEmail, &googlePublicKey{
 		kid:       dto.PrivateKeyId,
 		publicKey: &privateKey.PublicKey,
+	}, &privateKeyTokenSigner{
+		kid:        dto.PrivateKeyId,
+		privateKey: privateKey,
 	}, nil
 }
 
@@ -120,7 +288,7 @@ func (it *securityContextImpl) getGoogleProjectInfoFromMetadata() (projectId str
 func (it *securityContextImpl) initForGcp(ctx context.Context) error {
 	serviceAccountJson := it.gcp.serviceAccountJson
 	if serviceAccountJson == nil {
-		it.logInfo("load GOOGLE_APPLICATION_CREDENTIALS")
+		it.logger.Debug("load GOOGLE_APPLICATION_CREDENTIALS")
 
 		path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
 		if len(path) > 0 {
@@ -133,9 +301,9 @@ func (it *securityContextImpl) initForGcp(ctx context.Context) error {
 	}
 
 	if len(serviceAccountJson) > 0 {
-		it.logInfo("Init service account from JSON key")
+		it.logger.Debug("Init service account from JSON key")
 	} else {
-		it.logInfo("Init service account from System key")
+		it.logger.Debug("Init service account from System key")
 	}
 
 	// init Firebase App.
@@ -154,9 +322,38 @@ func (it *securityContextImpl) initForGcp(ctx context.Context) error {
 		return fmt.Errorf("firebase Auth initialize error: %w", err)
 	}
 
+	// init Firebase App per project.
+	var firebaseAuthByProject map[string]*auth.Client
+	if len(it.gcp.firebaseProjectIds) > 0 {
+		firebaseAuthByProject = make(map[string]*auth.Client)
+		for _, projectId := range it.gcp.firebaseProjectIds {
+			config := &firebase.Config{
+				ProjectID: projectId,
+			}
+			app, err := func() (*firebase.App, error) {
+				if len(serviceAccountJson) > 0 {
+					return firebase.NewApp(ctx, config, option.WithCredentialsJSON(serviceAccountJson))
+				} else {
+					return firebase.NewApp(ctx, config)
+				}
+			}()
+			if err != nil {
+				return fmt.Errorf("firebase App(%v) init failed: %w", projectId, err)
+			}
+			client, err := app.Auth(ctx)
+			if err != nil {
+				return fmt.Errorf("firebase Auth(%v) initialize error: %w", projectId, err)
+			}
+			firebaseAuthByProject[projectId] = client
+		}
+	}
+
 	// init ServiceControl.
 	serviceCtrl, err := func() (*servicecontrol.Service, error) {
-		if len(serviceAccountJson) > 0 {
+		if it.localApiKeys != nil {
+			it.logger.Info("ServiceControl is disabled, use local API Key file")
+			return nil, nil
+		} else if len(serviceAccountJson) > 0 {
 			return servicecontrol.NewService(ctx, option.WithCredentialsJSON(serviceAccountJson))
 		} else {
 			return servicecontrol.NewService(ctx)
@@ -168,77 +365,164 @@ func (it *securityContextImpl) initForGcp(ctx context.Context) error {
 
 	it.gcp.validApiKeys = cache.New(time.Hour, time.Minute)
 	it.gcp.firebaseAuth = firebaseAuth
+	it.gcp.firebaseAuthByProject = firebaseAuthByProject
 	it.gcp.serviceAccountJson = serviceAccountJson
 	it.gcp.serviceControlClient = serviceCtrl
 	if serviceAccountJson != nil {
-		it.logInfo("config load from JSON")
-		projectId, email, publicKey, err := it.getGoogleProjectInfoFromJson(serviceAccountJson)
+		it.logger.Debug("config load from JSON")
+		projectId, email, publicKey, signer, err := it.getGoogleProjectInfoFromJson(serviceAccountJson)
 		if err != nil {
 			return fmt.Errorf("ServiceAccount file parse failed: %w", err)
 		}
-		it.logInfo(fmt.Sprintf("GCP initialize success: %v", projectId))
+		it.gcp.tokenSigner = signer
 		it.gcp.clientEmail = email
 		it.gcp.projectId = projectId
-		keyCache := newGooglePublicKeyCache(
-			"https://www.googleapis.com/robot/v1/metadata/x509/"+url.PathEscape(email), it.logger)
+		keyCache := newGooglePublicKeyCache(getServiceAccountKeyUrl(email), it.logger, it.tracer, it.getMetrics())
+		keyCache.tokenTime = it.tokenTime
 		keyCache.addOfflineKey(publicKey)
-		err = keyCache.refreshKeys()
+		err = keyCache.refreshKeys(ctx)
 		if err != nil {
 			return fmt.Errorf("Public key refresh failed: %w", err)
 		}
 		it.gcp.serviceAccountPublicKeys = keyCache
 	} else {
-		it.logInfo("GCP config load from metadata")
+		it.logger.Debug("GCP config load from metadata")
 		projectId, email, err := it.getGoogleProjectInfoFromMetadata()
 		if err != nil {
 			return fmt.Errorf("Metadata parse failed: %w", err)
 		}
-		it.logInfo(fmt.Sprintf("GCP initialize success: %v", projectId))
 		it.gcp.clientEmail = email
 		it.gcp.projectId = projectId
-		keyCache := newGooglePublicKeyCache(
-			"https://www.googleapis.com/robot/v1/metadata/x509/"+url.PathEscape(email), it.logger)
-		err = keyCache.refreshKeys()
+		// private key is not available, sign original token by IAM Credentials API.
+		signer, err := newIamCredentialsTokenSigner(ctx, email)
+		if err != nil {
+			return err
+		}
+		it.gcp.tokenSigner = signer
+		keyCache := newGooglePublicKeyCache(getServiceAccountKeyUrl(email), it.logger, it.tracer, it.getMetrics())
+		keyCache.tokenTime = it.tokenTime
+		err = keyCache.refreshKeys(ctx)
 		if err != nil {
 			return fmt.Errorf("Public key refresh failed: %w", err)
 		}
 		it.gcp.serviceAccountPublicKeys = keyCache
 	}
 
-	it.logInfo("Google Cloud Platform load completed.")
-	it.logInfo(fmt.Sprintf("  * projectId: %v", it.gcp.projectId))
-	it.logInfo(fmt.Sprintf("  * service account: %v", it.gcp.clientEmail))
-	if it.gcp.serviceAccountPublicKeys.latestKey != nil {
-		it.logInfo(fmt.Sprintf("  * public key default: %v", it.gcp.serviceAccountPublicKeys.latestKey.kid))
+	var defaultKid string
+	latestKey, allKeys := it.gcp.serviceAccountPublicKeys.keys()
+	if latestKey != nil {
+		defaultKid = latestKey.kid
 	}
-	for kid, _ := range it.gcp.serviceAccountPublicKeys.allKeys {
-		it.logInfo(fmt.Sprintf("  * public key online: %v", kid))
+	onlineKids := make([]string, 0, len(allKeys))
+	for kid := range allKeys {
+		onlineKids = append(onlineKids, kid)
 	}
+	it.logger.Info("Google Cloud Platform load completed.",
+		slog.String("project_id", it.gcp.projectId),
+		slog.String("service_account", it.gcp.clientEmail),
+		slog.String("default_kid", defaultKid),
+		slog.Any("online_kids", onlineKids),
+		slog.Any("firebase_project_ids", it.gcp.firebaseProjectIds))
 
 	return nil
 }
 
+/*
+Returns true if service account json, GOOGLE_APPLICATION_CREDENTIALS or metadata server is available.
+*/
+func (it *securityContextImpl) hasGcpCredentials() bool {
+	return len(it.gcp.serviceAccountJson) > 0 ||
+		len(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")) > 0 ||
+		metadata.OnGCE()
+}
+
 /*
 Initialize context.
 */
 func (it *securityContextImpl) init(ctx context.Context) error {
 	if it.logger == nil {
-		it.logger = &Logger{}
+		it.logger = newSlogLogger(&Logger{})
+	}
+	if it.tracer == nil {
+		it.tracer = newTracer(nil)
+	}
+	if err := it.redaction.validate(); err != nil {
+		return err
 	}
-	if err := it.initForGcp(ctx); err != nil {
+	it.nonceStore = newMemoryNonceStore(100000, it.now)
+	if len(it.localApiKeyFile) > 0 {
+		store := newLocalApiKeyStore(it.localApiKeyFile, it.logger)
+		if err := store.load(); err != nil {
+			return err
+		}
+		it.localApiKeys = store
+	}
+	if it.localApiKeys != nil && !it.hasGcpCredentials() {
+		it.gcp.disabled = true
+		it.logger.Info("Google Cloud Platform is disabled, GCP credentials not found in local API Key file mode")
+	} else if err := it.initForGcp(ctx); err != nil {
 		return err
 	}
+	if it.keyRefreshInterval > 0 && it.gcp.serviceAccountPublicKeys != nil {
+		it.gcp.serviceAccountPublicKeys.startRefresh(it.keyRefreshInterval)
+	}
+	if len(it.auditSinks) > 0 {
+		chain, err := newAuditChain(ctx, it.auditHmacKey, it.auditChainHeadStore)
+		if err != nil {
+			return fmt.Errorf("audit chain init failed: %w", err)
+		}
+		it.audit = newAuditDispatcher(it.auditSinks, it.auditBufferSize, chain, it.logger)
+	}
 	return nil
 }
 
+func (it *securityContextImpl) Close(ctx context.Context) error {
+	var errs []error
+	if keyCache := it.gcp.serviceAccountPublicKeys; keyCache != nil {
+		if err := keyCache.stopRefresh(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("public key refresher stop failed: %w", err))
+		}
+	}
+	if it.audit != nil {
+		if err := it.audit.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("audit flush failed: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 /*
 New instance.
 */
 func NewSecurityContext(ctx context.Context, configs *SecurityContextConfigs) (SecurityContext, error) {
 	result := &securityContextImpl{}
 	if configs != nil {
-		result.logger = configs.Logger
+		if configs.SlogLogger != nil {
+			result.logger = configs.SlogLogger
+		} else if configs.SlogHandler != nil {
+			result.logger = slog.New(configs.SlogHandler)
+		} else if configs.Logger != nil {
+			result.logger = newSlogLogger(configs.Logger)
+		}
 		result.gcp.serviceAccountJson = configs.GoogleServiceAccountJson
+		result.localApiKeyFile = configs.LocalApiKeyFile
+		result.gcp.firebaseProjectIds = configs.FirebaseProjectIds
+		result.tracer = newTracer(configs.TracerProvider)
+		result.metrics = configs.Metrics
+		result.redaction = configs.Redaction
+		result.keyRefreshInterval = configs.KeyRefreshInterval
+		if configs.HealthThresholds != nil {
+			result.healthThresholds = *configs.HealthThresholds
+		}
+		result.tokenTime = &tokenTimePolicy{
+			clock:       configs.Clock,
+			leeway:      configs.TokenLeeway,
+			maxLifetime: configs.MaxTokenLifetime,
+		}
+		result.auditSinks = configs.AuditSinks
+		result.auditBufferSize = configs.AuditBufferSize
+		result.auditHmacKey = configs.AuditHmacKey
+		result.auditChainHeadStore = configs.AuditChainHeadStore
 	}
 	if err := result.init(ctx); err != nil {
 		return nil, err
@@ -2,11 +2,24 @@ package secure_backend
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"firebase.google.com/go/auth"
 	"github.com/stretchr/testify/assert"
 )
 
+/*
+Returns offline owner for tests, without GCP credentials.
+*/
+func newSecurityContextForTest() *securityContextImpl {
+	return &securityContextImpl{
+		logger:  newSlogLogger(&Logger{}),
+		tracer:  newTracer(nil),
+		metrics: &noopMetrics{},
+	}
+}
+
 func Test_securityContextImpl_init(t *testing.T) {
 	impl := &securityContextImpl{}
 	ctx := context.Background()
@@ -22,3 +35,26 @@ func Test_securityContextImpl_init(t *testing.T) {
 	assert.NotNil(t, impl.gcp.serviceAccountPublicKeys.latestKey)
 	assert.NotEmpty(t, impl.gcp.projectId)
 }
+
+func Test_securityContextImpl_getFirebaseAuth(t *testing.T) {
+	impl := &securityContextImpl{}
+	impl.gcp.firebaseAuth = &auth.Client{}
+
+	// default project only.
+	client, err := impl.getFirebaseAuth("any-project")
+	assert.NoError(t, err)
+	assert.Equal(t, impl.gcp.firebaseAuth, client)
+
+	production := &auth.Client{}
+	partner := &auth.Client{}
+	impl.gcp.firebaseAuthByProject = map[string]*auth.Client{
+		"production": production,
+		"partner":    partner,
+	}
+	client, err = impl.getFirebaseAuth("partner")
+	assert.NoError(t, err)
+	assert.Same(t, partner, client)
+
+	_, err = impl.getFirebaseAuth("unknown")
+	assert.True(t, errors.Is(err, ErrInvalidAudience))
+}
@@ -0,0 +1,116 @@
+package secure_backend
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Sign-in requirements for Firebase user, enforced in FirebaseAuthVerifier.Verify().
+Original token is checked by sign-in info carried by TokenExchanger.Exchange(),
+original token without sign-in info is denied(ErrSignInProviderNotAllowed).
+
+e.g.)
+
+	verifier.SetSignInRequirements(&SignInRequirements{
+		DenyAnonymous:        true,
+		RequireEmailVerified: true,
+		AllowedProviders:     []string{"google.com", "apple.com"},
+	})
+*/
+type SignInRequirements struct {
+	/*
+		Deny anonymous user(ErrAnonymousUser).
+	*/
+	DenyAnonymous bool
+
+	/*
+		Require 'email_verified' claim(ErrEmailNotVerified).
+	*/
+	RequireEmailVerified bool
+
+	/*
+		Allowed 'firebase.sign_in_provider'(ErrSignInProviderNotAllowed).
+		If empty, then all providers are allowed.
+		e.g.) "password", "google.com", "apple.com", "phone", "custom"
+	*/
+	AllowedProviders []string
+
+	/*
+		Allowed email domains(ErrEmailDomainNotAllowed).
+		If empty, then all domains are allowed.
+		Email must be verified, unverified email is not proof of domain(ErrEmailNotVerified).
+		e.g.) "example.com"
+	*/
+	AllowedEmailDomains []string
+}
+
+/*
+Sign-in requirement violation.
+Error matches both of kind(e.g. ErrAnonymousUser) and ErrPermissionDenied.
+*/
+type SignInRequirementError struct {
+	/*
+		ErrAnonymousUser, ErrEmailNotVerified, ErrSignInProviderNotAllowed or ErrEmailDomainNotAllowed.
+	*/
+	Kind error
+
+	Message string
+}
+
+func (it *SignInRequirementError) Error() string {
+	return fmt.Sprintf("%v: %v", it.Kind, it.Message)
+}
+
+func (it *SignInRequirementError) Is(target error) bool {
+	return target == it.Kind || target == ErrPermissionDenied
+}
+
+/*
+Returns error if user violates requirements.
+*/
+func (it *SignInRequirements) check(user *FirebaseUser) error {
+	if it == nil {
+		return nil
+	}
+
+	if it.DenyAnonymous && user.SignInProvider == "anonymous" {
+		return &SignInRequirementError{Kind: ErrAnonymousUser, Message: "anonymous user is denied"}
+	}
+	if len(it.AllowedProviders) > 0 && !containsString(it.AllowedProviders, user.SignInProvider) {
+		return &SignInRequirementError{
+			Kind:    ErrSignInProviderNotAllowed,
+			Message: fmt.Sprintf("sign in provider(%v) is not allowed", user.SignInProvider),
+		}
+	}
+	if (it.RequireEmailVerified || len(it.AllowedEmailDomains) > 0) && (len(user.Email) == 0 || !user.EmailVerified) {
+		return &SignInRequirementError{Kind: ErrEmailNotVerified, Message: "email is not verified"}
+	}
+	if len(it.AllowedEmailDomains) > 0 {
+		domain := strings.ToLower(user.Email[strings.LastIndex(user.Email, "@")+1:])
+		for _, allowed := range it.AllowedEmailDomains {
+			if strings.ToLower(allowed) == domain {
+				return nil
+			}
+		}
+		return &SignInRequirementError{
+			Kind:    ErrEmailDomainNotAllowed,
+			Message: fmt.Sprintf("email domain(%v) is not allowed", domain),
+		}
+	}
+	return nil
+}
+
+/*
+Returns error if original token user violates requirements.
+Sign-in provider is unknown if token is not exchanged from Firebase user, then it is denied.
+*/
+func (it *SignInRequirements) checkOriginal(user *FirebaseUser) error {
+	if it == nil {
+		return nil
+	}
+	if len(user.SignInProvider) == 0 {
+		return &SignInRequirementError{Kind: ErrSignInProviderNotAllowed, Message: "sign in provider of original token is unknown"}
+	}
+	return it.check(user)
+}
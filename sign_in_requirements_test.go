@@ -0,0 +1,60 @@
+package secure_backend
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignInRequirements_check(t *testing.T) {
+	requirements := &SignInRequirements{
+		DenyAnonymous:        true,
+		RequireEmailVerified: true,
+		AllowedProviders:     []string{"google.com", "password"},
+		AllowedEmailDomains:  []string{"Example.com"},
+	}
+
+	assert.NoError(t, requirements.check(&FirebaseUser{
+		Email:          "user@example.COM",
+		EmailVerified:  true,
+		SignInProvider: "google.com",
+	}))
+
+	for name, test := range map[string]struct {
+		user *FirebaseUser
+		kind error
+	}{
+		"anonymous":  {&FirebaseUser{SignInProvider: "anonymous"}, ErrAnonymousUser},
+		"provider":   {&FirebaseUser{SignInProvider: "apple.com", Email: "user@example.com", EmailVerified: true}, ErrSignInProviderNotAllowed},
+		"unverified": {&FirebaseUser{SignInProvider: "password", Email: "user@example.com"}, ErrEmailNotVerified},
+		"no email":   {&FirebaseUser{SignInProvider: "password", EmailVerified: true}, ErrEmailNotVerified},
+		"domain":     {&FirebaseUser{SignInProvider: "password", Email: "user@example.com.evil.test", EmailVerified: true}, ErrEmailDomainNotAllowed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := requirements.check(test.user)
+			assert.True(t, errors.Is(err, test.kind), err)
+			assert.True(t, errors.Is(err, ErrPermissionDenied))
+			assert.Equal(t, http.StatusForbidden, getHttpStatusCode(err))
+
+			var requirementErr *SignInRequirementError
+			assert.True(t, errors.As(err, &requirementErr))
+		})
+	}
+
+	assert.Equal(t, "anonymous_user", getErrorClass(requirements.check(&FirebaseUser{SignInProvider: "anonymous"})))
+
+	// no requirements
+	var empty *SignInRequirements
+	assert.NoError(t, empty.check(&FirebaseUser{SignInProvider: "anonymous"}))
+	assert.NoError(t, (&SignInRequirements{}).check(&FirebaseUser{SignInProvider: "anonymous"}))
+}
+
+func TestSignInRequirements_checkOriginal(t *testing.T) {
+	requirements := &SignInRequirements{DenyAnonymous: true}
+	assert.NoError(t, requirements.checkOriginal(&FirebaseUser{SignInProvider: "google.com"}))
+	assert.True(t, errors.Is(requirements.checkOriginal(&FirebaseUser{SignInProvider: "anonymous"}), ErrAnonymousUser))
+	assert.True(t, errors.Is(requirements.checkOriginal(&FirebaseUser{}), ErrSignInProviderNotAllowed))
+	assert.NoError(t, (*SignInRequirements)(nil).checkOriginal(&FirebaseUser{}))
+}
@@ -0,0 +1,168 @@
+package secure_backend
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+HTTP headers for signed request.
+*/
+const (
+	SignedRequestClientIdHeader  = "X-Client-Id"
+	SignedRequestTimestampHeader = "X-Signature-Timestamp"
+	SignedRequestNonceHeader     = "X-Signature-Nonce"
+	SignedRequestSignatureHeader = "X-Signature"
+)
+
+/*
+Max request body size of signed request.
+*/
+const maxSignedRequestBodySize = 10 << 20
+
+/*
+Signed request.
+*/
+type SignedRequest struct {
+	/*
+		Client id, key of ClientSecretStore.
+	*/
+	ClientId string
+
+	/*
+		HTTP method.
+		e.g.) "POST"
+	*/
+	Method string
+
+	/*
+		Request path.
+		e.g.) "/api/v1/orders"
+	*/
+	Path string
+
+	/*
+		Canonical query string, sorted by key.
+		e.g.) "limit=10&page=2"
+	*/
+	Query string
+
+	/*
+		Request body, or nil.
+	*/
+	Body []byte
+
+	/*
+		Signed time.
+	*/
+	Timestamp time.Time
+
+	/*
+		Unique value per request.
+	*/
+	Nonce string
+
+	/*
+		Hex encoded HMAC-SHA256 signature.
+	*/
+	Signature string
+}
+
+/*
+Build signed request from HTTP request.
+Request body is read up to 10MiB, and restored to r.Body.
+*/
+func NewSignedRequest(r *http.Request) (*SignedRequest, error) {
+	var body []byte
+	if r.Body != nil {
+		read, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxSignedRequestBodySize))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, newVerificationError(ErrMalformedToken, "request body is too large", err)
+		} else if err != nil {
+			return nil, fmt.Errorf("request body read failed: %w", err)
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(read))
+		body = read
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(SignedRequestTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, newVerificationError(ErrMalformedToken, fmt.Sprintf("invalid %v header", SignedRequestTimestampHeader), err)
+	}
+
+	return &SignedRequest{
+		ClientId:  r.Header.Get(SignedRequestClientIdHeader),
+		Method:    r.Method,
+		Path:      r.URL.EscapedPath(),
+		Query:     r.URL.Query().Encode(),
+		Body:      body,
+		Timestamp: time.Unix(timestamp, 0),
+		Nonce:     r.Header.Get(SignedRequestNonceHeader),
+		Signature: r.Header.Get(SignedRequestSignatureHeader),
+	}, nil
+}
+
+/*
+Returns canonical request for signature.
+
+	METHOD + "\n" + PATH + "\n" + QUERY + "\n" + hex(sha256(BODY)) + "\n" + UNIX_TIMESTAMP + "\n" + NONCE
+
+QUERY is sorted by key, and URL encoded(url.Values.Encode()).
+*/
+func (it *SignedRequest) canonicalString() string {
+	bodyHash := sha256.Sum256(it.Body)
+	return strings.Join([]string{
+		strings.ToUpper(it.Method),
+		it.Path,
+		getCanonicalQuery(it.Query),
+		hex.EncodeToString(bodyHash[:]),
+		strconv.FormatInt(it.Timestamp.Unix(), 10),
+		it.Nonce,
+	}, "\n")
+}
+
+/*
+Returns query sorted by key, or raw query if it is not parsed.
+*/
+func getCanonicalQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	return values.Encode()
+}
+
+func (it *SignedRequest) validate() error {
+	if len(it.ClientId) == 0 {
+		return errors.New("client id is empty")
+	} else if len(it.Method) == 0 || len(it.Path) == 0 {
+		return errors.New("method or path is empty")
+	} else if len(it.Nonce) == 0 {
+		return errors.New("nonce is empty")
+	} else if len(it.Signature) == 0 {
+		return errors.New("signature is empty")
+	}
+	return nil
+}
+
+/*
+Returns hex encoded HMAC-SHA256 signature for request.
+Client should set this value to 'X-Signature' header.
+*/
+func SignRequest(secret []byte, request *SignedRequest) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(request.canonicalString()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
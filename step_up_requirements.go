@@ -0,0 +1,123 @@
+package secure_backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+Step-up authentication requirements, per call.
+for sensitive operations, e.g.) payouts, account deletion.
+
+see) https://www.rfc-editor.org/rfc/rfc9470
+*/
+type StepUpRequirements struct {
+	/*
+		Maximum time since authentication('auth_time').
+		If zero, then not checked.
+	*/
+	MaxAuthAge time.Duration
+
+	/*
+		Required second factor('firebase.sign_in_second_factor').
+		If empty, then not checked.
+		e.g.) "phone", "totp"
+	*/
+	SecondFactor string
+}
+
+/*
+Step-up authentication is required.
+Client should re-authenticate, then retry with new token.
+*/
+type StepUpRequiredError struct {
+	/*
+		Required maximum time since authentication, or zero.
+	*/
+	MaxAuthAge time.Duration
+
+	/*
+		Required second factor, or empty.
+	*/
+	SecondFactor string
+
+	Message string
+}
+
+func (it *StepUpRequiredError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrStepUpRequired, it.Message)
+}
+
+func (it *StepUpRequiredError) Is(target error) bool {
+	return target == ErrStepUpRequired
+}
+
+/*
+Returns 'WWW-Authenticate' header value.
+acr_values is required second factor.
+
+e.g.) Bearer error="insufficient_user_authentication", error_description="authentication is too old", max_age=300
+*/
+func (it *StepUpRequiredError) WWWAuthenticate() string {
+	params := []string{
+		`error="insufficient_user_authentication"`,
+		fmt.Sprintf("error_description=%q", it.Message),
+	}
+	if it.MaxAuthAge > 0 {
+		params = append(params, fmt.Sprintf("max_age=%v", int64(it.MaxAuthAge.Seconds())))
+	}
+	if len(it.SecondFactor) > 0 {
+		params = append(params, fmt.Sprintf("acr_values=%q", it.SecondFactor))
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+/*
+Returns error if token does not satisfy requirements.
+*/
+func (it *StepUpRequirements) check(token *VerifiedFirebaseAuthToken, now time.Time) error {
+	if it == nil {
+		return nil
+	}
+	newError := func(message string) error {
+		return &StepUpRequiredError{
+			MaxAuthAge:   it.MaxAuthAge,
+			SecondFactor: it.SecondFactor,
+			Message:      message,
+		}
+	}
+
+	if it.MaxAuthAge > 0 {
+		if token.AuthTime.IsZero() {
+			return newError("authentication time is unknown")
+		} else if now.Sub(token.AuthTime) > it.MaxAuthAge {
+			return newError("authentication is too old")
+		}
+	}
+	if len(it.SecondFactor) > 0 && token.SecondFactor != it.SecondFactor {
+		return newError(fmt.Sprintf("second factor(%v) is required", it.SecondFactor))
+	}
+	return nil
+}
+
+/*
+Returns authentication time and second factor from token claims.
+*/
+func getAuthenticationInfo(claims map[string]interface{}) (authTime time.Time, secondFactor string) {
+	switch value := claims["auth_time"].(type) {
+	case float64:
+		authTime = time.Unix(int64(value), 0)
+	case int64:
+		authTime = time.Unix(value, 0)
+	case json.Number:
+		if t, err := value.Int64(); err == nil {
+			authTime = time.Unix(t, 0)
+		}
+	}
+	if firebase, ok := claims["firebase"].(map[string]interface{}); ok {
+		secondFactor, _ = firebase["sign_in_second_factor"].(string)
+	}
+	return authTime, secondFactor
+}
@@ -0,0 +1,120 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepUpRequirements_check(t *testing.T) {
+	now := time.Now()
+	requirements := &StepUpRequirements{
+		MaxAuthAge:   5 * time.Minute,
+		SecondFactor: "totp",
+	}
+
+	assert.NoError(t, requirements.check(&VerifiedFirebaseAuthToken{
+		AuthTime:     now.Add(-time.Minute),
+		SecondFactor: "totp",
+	}, now))
+
+	for name, token := range map[string]*VerifiedFirebaseAuthToken{
+		"unknown auth time": {SecondFactor: "totp"},
+		"old auth time":     {AuthTime: now.Add(-time.Hour), SecondFactor: "totp"},
+		"no second factor":  {AuthTime: now},
+		"other factor":      {AuthTime: now, SecondFactor: "phone"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := requirements.check(token, now)
+			assert.True(t, errors.Is(err, ErrStepUpRequired), err)
+			assert.False(t, errors.Is(err, ErrPermissionDenied))
+			assert.Equal(t, http.StatusUnauthorized, getHttpStatusCode(err))
+			assert.Equal(t, "step_up_required", getErrorClass(err))
+		})
+	}
+
+	// no requirements
+	var empty *StepUpRequirements
+	assert.NoError(t, empty.check(&VerifiedFirebaseAuthToken{}, now))
+	assert.NoError(t, (&StepUpRequirements{}).check(&VerifiedFirebaseAuthToken{}, now))
+}
+
+func TestStepUpRequiredError_WWWAuthenticate(t *testing.T) {
+	err := &StepUpRequiredError{
+		MaxAuthAge:   5 * time.Minute,
+		SecondFactor: "totp",
+		Message:      "authentication is too old",
+	}
+	assert.Equal(t, `Bearer error="insufficient_user_authentication", error_description="authentication is too old", max_age=300, acr_values="totp"`, err.WWWAuthenticate())
+
+	err = &StepUpRequiredError{Message: "second factor(phone) is required", SecondFactor: "phone"}
+	assert.Equal(t, `Bearer error="insufficient_user_authentication", error_description="second factor(phone) is required", acr_values="phone"`, err.WWWAuthenticate())
+}
+
+func TestGetAuthenticationInfo(t *testing.T) {
+	authTime, secondFactor := getAuthenticationInfo(map[string]interface{}{
+		"auth_time": float64(1700000000),
+		"firebase": map[string]interface{}{
+			"sign_in_provider":      "password",
+			"sign_in_second_factor": "phone",
+		},
+	})
+	assert.Equal(t, int64(1700000000), authTime.Unix())
+	assert.Equal(t, "phone", secondFactor)
+
+	authTime, secondFactor = getAuthenticationInfo(map[string]interface{}{})
+	assert.True(t, authTime.IsZero())
+	assert.Empty(t, secondFactor)
+}
+
+func TestNewFirebaseAuthStepUpMiddleware(t *testing.T) {
+	ctx := context.Background()
+	owner := newOriginalTokenOwnerForTest(t)
+	verifier := owner.NewFirebaseAuthVerifier()
+	verifier.AcceptOriginalToken()
+
+	sign := func(authTime time.Time) string {
+		token, err := owner.gcp.tokenSigner.sign(ctx, jwt.MapClaims{
+			"iss":       owner.gcp.clientEmail,
+			"sub":       owner.gcp.clientEmail,
+			"aud":       firebaseCustomTokenAudience,
+			"uid":       "user-1",
+			"iat":       time.Now().Unix(),
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"auth_time": authTime.Unix(),
+		})
+		assert.NoError(t, err)
+		return token
+	}
+
+	handler := NewFirebaseAuthStepUpMiddleware(verifier, &StepUpRequirements{
+		MaxAuthAge: 5 * time.Minute,
+	}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verified := VerifiedFirebaseAuthTokenFromContext(r.Context())
+		assert.NotNil(t, verified)
+		assert.False(t, verified.AuthTime.IsZero())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/account/delete", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request(sign(time.Now().Add(-time.Minute)))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+
+	w = request(sign(time.Now().Add(-time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="insufficient_user_authentication", error_description="authentication is too old", max_age=300`, w.Header().Get("WWW-Authenticate"))
+}
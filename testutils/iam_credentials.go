@@ -0,0 +1,132 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+/*
+Local IAM Credentials API for tests.
+Serves signJwt API, and service account public keys(X.509 certificates) like www.googleapis.com.
+
+e.g.)
+
+	fake := testutils.NewFakeIamCredentials()
+	service, err := iamcredentials.NewService(ctx, option.WithEndpoint(fake.Url()), option.WithoutAuthentication())
+*/
+type FakeIamCredentials struct {
+	server *httptest.Server
+
+	lock       sync.Mutex
+	kid        string
+	privateKey *rsa.PrivateKey
+	cert       string
+	signCount  int
+}
+
+func NewFakeIamCredentials() *FakeIamCredentials {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake-iam-credentials"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	result := &FakeIamCredentials{
+		kid:        "fake-iam-key",
+		privateKey: privateKey,
+		cert:       string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+
+	mux := http.NewServeMux()
+	// POST /v1/projects/-/serviceAccounts/<email>:signJwt
+	mux.HandleFunc("/v1/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, ":signJwt") {
+			http.NotFound(w, r)
+			return
+		}
+		request := struct {
+			Payload string `json:"payload"`
+		}{}
+		claims := jwt.MapClaims{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err := json.Unmarshal([]byte(request.Payload), &claims); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result.lock.Lock()
+		defer result.lock.Unlock()
+		result.signCount++
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = result.kid
+		signed, err := token.SignedString(result.privateKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keyId":     result.kid,
+			"signedJwt": signed,
+		})
+	})
+	// GET /robot/v1/metadata/x509/<email>
+	mux.HandleFunc("/robot/v1/metadata/x509/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			result.kid: result.cert,
+		})
+	})
+	result.server = httptest.NewServer(mux)
+	return result
+}
+
+/*
+Returns API endpoint, for option.WithEndpoint().
+*/
+func (it *FakeIamCredentials) Url() string {
+	return it.server.URL + "/"
+}
+
+/*
+Returns public key URL of service account.
+*/
+func (it *FakeIamCredentials) KeyUrl(serviceAccount string) string {
+	return it.server.URL + "/robot/v1/metadata/x509/" + serviceAccount
+}
+
+/*
+Returns count of signJwt calls.
+*/
+func (it *FakeIamCredentials) SignCount() int {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	return it.signCount
+}
+
+func (it *FakeIamCredentials) Close() {
+	it.server.Close()
+}
@@ -0,0 +1,104 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/golang-jwt/jwt"
+)
+
+/*
+Local OpenID Connect issuer for tests.
+Serves discovery document and JWKS, and signs tokens by RS256.
+*/
+type FakeOidcIssuer struct {
+	server *httptest.Server
+
+	lock       sync.Mutex
+	kid        string
+	privateKey *rsa.PrivateKey
+	generation int
+}
+
+func NewFakeOidcIssuer() *FakeOidcIssuer {
+	result := &FakeOidcIssuer{}
+	result.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":   result.Url(),
+			"jwks_uri": result.Url() + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		result.lock.Lock()
+		defer result.lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]interface{}{
+				{
+					"kty": "RSA",
+					"use": "sig",
+					"alg": "RS256",
+					"kid": result.kid,
+					"n":   base64.RawURLEncoding.EncodeToString(result.privateKey.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(result.privateKey.E)).Bytes()),
+				},
+			},
+		})
+	})
+	result.server = httptest.NewServer(mux)
+	return result
+}
+
+/*
+Returns issuer URL.
+*/
+func (it *FakeOidcIssuer) Url() string {
+	return it.server.URL
+}
+
+/*
+Generate new signing key, old key is removed from JWKS.
+*/
+func (it *FakeOidcIssuer) RotateKey() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	it.lock.Lock()
+	defer it.lock.Unlock()
+	it.generation++
+	it.kid = fmt.Sprintf("fake-key-%v", it.generation)
+	it.privateKey = privateKey
+}
+
+/*
+Returns signed token by current key.
+*/
+func (it *FakeOidcIssuer) Sign(claims map[string]interface{}) string {
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	token.Header["kid"] = it.kid
+	signed, err := token.SignedString(it.privateKey)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (it *FakeOidcIssuer) Close() {
+	it.server.Close()
+}
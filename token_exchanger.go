@@ -0,0 +1,80 @@
+package secure_backend
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+/*
+Token exchange request.
+see) https://www.rfc-editor.org/rfc/rfc8693
+*/
+type TokenExchangeRequest struct {
+	/*
+		Target service.
+		e.g.) "https://orders.internal.example.com"
+	*/
+	Audience string
+
+	/*
+		Allowed scopes.
+		If subject is original token, then these must be subset of its scopes.
+		Original token without scope can not be exchanged for any scope.
+	*/
+	Scopes []string
+
+	/*
+		Claim names copied from subject token.
+		Sign-in provider and 'email_verified' are always copied, for SignInRequirements of receiver.
+		e.g.) []string{"tenant", "roles"}
+	*/
+	Claims []string
+
+	/*
+		Token lifetime.
+		Token never outlives subject token.
+		default) 5 minutes, max) 1 hour
+	*/
+	Lifetime time.Duration
+}
+
+/*
+Exchanged original token.
+*/
+type ExchangedToken struct {
+	/*
+		Signed JWT, verified by FirebaseAuthVerifier with AcceptOriginalToken().
+	*/
+	Token string
+
+	Audience string
+
+	Scopes []string
+
+	ExpireAt time.Time
+}
+
+/*
+Exchange verified token for down-scoped original token, signed by service account.
+Exchanged token has 'act' claim(this service account), so receiver can trace delegation chain.
+
+e.g.)
+
+	verified, err := verifier.Verify(ctx, idToken)
+	exchanged, err := exchanger.Exchange(ctx, verified, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+		Scopes:   []string{"orders.read"},
+		Claims:   []string{"tenant"},
+	})
+*/
+type TokenExchanger interface {
+	// Set custom logger.
+	SetLogger(logger *Logger)
+
+	// Set custom structured logger.
+	SetSlogLogger(logger *slog.Logger)
+
+	// Returns new token for audience.
+	Exchange(ctx context.Context, subject *VerifiedFirebaseAuthToken, request *TokenExchangeRequest) (*ExchangedToken, error)
+}
@@ -0,0 +1,151 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	defaultExchangedTokenLifetime = 5 * time.Minute
+	maxExchangedTokenLifetime     = time.Hour
+)
+
+/*
+Claims which can not be copied to exchanged token.
+*/
+var reservedExchangeClaims = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "iat": true, "nbf": true, "jti": true,
+	"uid": true, "act": true, "scope": true, "claims": true, "firebase": true, "auth_time": true,
+}
+
+type tokenExchangerImpl struct {
+	owner *securityContextImpl
+
+	logger *slog.Logger
+}
+
+func (it *tokenExchangerImpl) SetLogger(logger *Logger) {
+	it.logger = newSlogLogger(logger)
+}
+
+func (it *tokenExchangerImpl) SetSlogLogger(logger *slog.Logger) {
+	it.logger = logger
+}
+
+/*
+Returns 'act' claim, current actor is this service account.
+*/
+func (it *tokenExchangerImpl) newActClaim(subject *VerifiedFirebaseAuthToken) map[string]interface{} {
+	act := map[string]interface{}{
+		"sub": it.owner.gcp.clientEmail,
+	}
+	if prev, ok := subject.Claims["act"].(map[string]interface{}); ok {
+		act["act"] = prev
+	}
+	return act
+}
+
+func (it *tokenExchangerImpl) Exchange(ctx context.Context, subject *VerifiedFirebaseAuthToken, request *TokenExchangeRequest) (result *ExchangedToken, err error) {
+	defer func() {
+		var uid string
+		if subject != nil && subject.User != nil {
+			uid = subject.User.Id
+		}
+		outcome, reasonCode := getAuditOutcome(err)
+		it.owner.emitAudit(ctx, &AuditEvent{
+			Principal:  it.owner.redaction.identifier(uid),
+			Method:     "exchange",
+			Verifier:   "token_exchange",
+			Outcome:    outcome,
+			ReasonCode: reasonCode,
+		})
+	}()
+
+	signer := it.owner.gcp.tokenSigner
+	if signer == nil {
+		return nil, errors.New("token signer is not available")
+	} else if subject == nil || subject.User == nil || len(subject.User.Id) == 0 {
+		return nil, errors.New("subject token is empty")
+	} else if len(request.Audience) == 0 {
+		return nil, errors.New("TokenExchangeRequest.Audience is empty")
+	}
+
+	// down-scope only, original token without scope can not grant any scope.
+	// Firebase ID token has no scope set(nil), then any scope can be requested.
+	if subject.Scopes != nil {
+		for _, scope := range request.Scopes {
+			if !containsString(subject.Scopes, scope) {
+				return nil, newVerificationError(ErrPermissionDenied, fmt.Sprintf("scope(%v) is not granted to subject", scope), nil)
+			}
+		}
+	}
+
+	claims := map[string]interface{}{}
+	for _, key := range request.Claims {
+		if reservedExchangeClaims[key] {
+			return nil, fmt.Errorf("claim(%v) is reserved", key)
+		}
+		if value, ok := subject.Claims[key]; ok {
+			claims[key] = value
+		}
+	}
+
+	// sign-in info, checked by SignInRequirements of receiver.
+	if len(subject.User.SignInProvider) > 0 {
+		claims["firebase"] = map[string]interface{}{
+			"sign_in_provider": subject.User.SignInProvider,
+		}
+	}
+	if subject.User.EmailVerified {
+		claims["email_verified"] = true
+	}
+
+	lifetime := request.Lifetime
+	if lifetime <= 0 {
+		lifetime = defaultExchangedTokenLifetime
+	} else if lifetime > maxExchangedTokenLifetime {
+		lifetime = maxExchangedTokenLifetime
+	}
+	now := it.owner.now()
+	expireAt := now.Add(lifetime)
+	if !subject.ExpireAt.IsZero() && subject.ExpireAt.Before(expireAt) {
+		expireAt = subject.ExpireAt
+	}
+
+	token, err := signer.sign(ctx, jwt.MapClaims{
+		"iss":    it.owner.gcp.clientEmail,
+		"sub":    it.owner.gcp.clientEmail,
+		"aud":    request.Audience,
+		"iat":    now.Unix(),
+		"exp":    expireAt.Unix(),
+		"uid":    subject.User.Id,
+		"scope":  strings.Join(request.Scopes, " "),
+		"act":    it.newActClaim(subject),
+		"claims": claims,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("exchanged token sign failed: %w", err)
+	}
+	// signed token may be cached, it expires earlier.
+	if expireAt, err = getSignedTokenExpireAt(token); err != nil {
+		return nil, fmt.Errorf("exchanged token sign failed: %w", err)
+	}
+
+	it.logger.Info("token exchanged",
+		it.owner.redaction.subjectAttr(subject.User.Id),
+		slog.String("aud", request.Audience),
+		slog.Any("scopes", request.Scopes),
+		slog.Time("expire_at", expireAt))
+	return &ExchangedToken{
+		Token:    token,
+		Audience: request.Audience,
+		Scopes:   request.Scopes,
+		ExpireAt: expireAt,
+	}, nil
+}
@@ -0,0 +1,189 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+Returns security context with service account key on memory, without GCP.
+*/
+func newOriginalTokenOwnerForTest(t *testing.T) *securityContextImpl {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	owner := newSecurityContextForTest()
+	owner.gcp.projectId = "example"
+	owner.gcp.clientEmail = "edge@example.iam.gserviceaccount.com"
+	owner.gcp.serviceAccountPublicKeys = newGooglePublicKeyCache("http://127.0.0.1:0/unused", owner.logger, owner.tracer, owner.metrics)
+	owner.gcp.serviceAccountPublicKeys.addOfflineKey(&googlePublicKey{kid: "test-key", publicKey: &privateKey.PublicKey})
+	owner.gcp.tokenSigner = &privateKeyTokenSigner{kid: "test-key", privateKey: privateKey}
+	return owner
+}
+
+func TestTokenExchangerImpl_Exchange(t *testing.T) {
+	ctx := context.Background()
+	owner := newOriginalTokenOwnerForTest(t)
+	exchanger := owner.NewTokenExchanger()
+
+	subject := newVerifiedFirebaseAuthTokenForTest("user-1", map[string]interface{}{
+		"tenant": "example",
+		"email":  "user@example.com",
+	})
+	subject.ExpireAt = time.Now().Add(time.Hour)
+
+	exchanged, err := exchanger.Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+		Scopes:   []string{"orders.read", "orders.write"},
+		Claims:   []string{"tenant"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, exchanged.ExpireAt.Before(time.Now().Add(6*time.Minute)))
+
+	// receiver
+	verifier := owner.NewFirebaseAuthVerifier()
+	verifier.AcceptOriginalToken()
+	_, err = verifier.Verify(ctx, exchanged.Token)
+	assert.True(t, errors.Is(err, ErrInvalidAudience))
+
+	verifier.AcceptOriginalTokenAudiences("https://orders.internal.example.com")
+	verified, err := verifier.Verify(ctx, exchanged.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", verified.User.Id)
+	assert.Equal(t, "example", verified.Claims["tenant"])
+	assert.Nil(t, verified.Claims["email"])
+	assert.Equal(t, []string{"orders.read", "orders.write"}, verified.Scopes)
+	assert.Equal(t, []string{"edge@example.iam.gserviceaccount.com"}, verified.Actors)
+	assert.NoError(t, Authorize(ctx, RequireScopes("orders.read"), verified))
+	assert.True(t, errors.Is(Authorize(ctx, RequireScopes("orders.delete"), verified), ErrPermissionDenied))
+
+	// exchange again, down-scope only.
+	_, err = exchanger.Exchange(ctx, verified, &TokenExchangeRequest{
+		Audience: "https://billing.internal.example.com",
+		Scopes:   []string{"orders.delete"},
+	})
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+
+	chained, err := exchanger.Exchange(ctx, verified, &TokenExchangeRequest{
+		Audience: "https://billing.internal.example.com",
+		Scopes:   []string{"orders.read"},
+	})
+	assert.NoError(t, err)
+	verifier.AcceptOriginalTokenAudiences("https://billing.internal.example.com")
+	verified, err = verifier.Verify(ctx, chained.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"edge@example.iam.gserviceaccount.com", "edge@example.iam.gserviceaccount.com"}, verified.Actors)
+
+	// original token without scope, can not be up-scoped.
+	unscoped, err := exchanger.Exchange(ctx, verified, &TokenExchangeRequest{
+		Audience: "https://billing.internal.example.com",
+	})
+	assert.NoError(t, err)
+	verified, err = verifier.Verify(ctx, unscoped.Token)
+	assert.NoError(t, err)
+	assert.NotNil(t, verified.Scopes)
+	assert.Empty(t, verified.Scopes)
+	_, err = exchanger.Exchange(ctx, verified, &TokenExchangeRequest{
+		Audience: "https://billing.internal.example.com",
+		Scopes:   []string{"orders.read"},
+	})
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+}
+
+func TestTokenExchangerImpl_Exchange_errors(t *testing.T) {
+	ctx := context.Background()
+	owner := newOriginalTokenOwnerForTest(t)
+	exchanger := owner.NewTokenExchanger()
+	subject := newVerifiedFirebaseAuthTokenForTest("user-1", map[string]interface{}{})
+
+	// reserved claim
+	_, err := exchanger.Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+		Claims:   []string{"uid"},
+	})
+	assert.Error(t, err)
+
+	// no audience
+	_, err = exchanger.Exchange(ctx, subject, &TokenExchangeRequest{})
+	assert.Error(t, err)
+
+	// not outlive subject
+	subject.ExpireAt = time.Now().Add(time.Minute)
+	exchanged, err := exchanger.Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+		Lifetime: time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, subject.ExpireAt.Unix(), exchanged.ExpireAt.Unix())
+
+	// no private key
+	owner.gcp.tokenSigner = nil
+	_, err = exchanger.Exchange(ctx, subject, &TokenExchangeRequest{
+		Audience: "https://orders.internal.example.com",
+	})
+	assert.Error(t, err)
+}
+
+func TestTokenExchangerImpl_Exchange_signInRequirements(t *testing.T) {
+	ctx := context.Background()
+	owner := newOriginalTokenOwnerForTest(t)
+	exchanger := owner.NewTokenExchanger()
+	const audience = "https://orders.internal.example.com"
+
+	verifier := owner.NewFirebaseAuthVerifier()
+	verifier.AcceptOriginalTokenAudiences(audience)
+	verifier.AcceptOriginalToken()
+	verifier.SetSignInRequirements(&SignInRequirements{
+		DenyAnonymous:        true,
+		RequireEmailVerified: true,
+	})
+
+	exchange := func(user *FirebaseUser) string {
+		subject := newVerifiedFirebaseAuthTokenForTest(user.Id, map[string]interface{}{
+			"email": user.Email,
+		})
+		subject.User = user
+		exchanged, err := exchanger.Exchange(ctx, subject, &TokenExchangeRequest{
+			Audience: audience,
+			Claims:   []string{"email"},
+		})
+		assert.NoError(t, err)
+		return exchanged.Token
+	}
+
+	verified, err := verifier.Verify(ctx, exchange(&FirebaseUser{
+		Id:             "user-1",
+		Email:          "user@example.com",
+		EmailVerified:  true,
+		SignInProvider: "google.com",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "google.com", verified.User.SignInProvider)
+	assert.True(t, verified.User.EmailVerified)
+
+	_, err = verifier.Verify(ctx, exchange(&FirebaseUser{Id: "user-2", SignInProvider: "anonymous"}))
+	assert.True(t, errors.Is(err, ErrAnonymousUser), err)
+
+	_, err = verifier.Verify(ctx, exchange(&FirebaseUser{Id: "user-3", Email: "user@example.com", SignInProvider: "password"}))
+	assert.True(t, errors.Is(err, ErrEmailNotVerified), err)
+
+	// original token without sign-in info.
+	token, err := owner.gcp.tokenSigner.sign(ctx, jwt.MapClaims{
+		"iss": owner.gcp.clientEmail,
+		"sub": owner.gcp.clientEmail,
+		"aud": audience,
+		"uid": "user-4",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, token)
+	assert.True(t, errors.Is(err, ErrSignInProviderNotAllowed), err)
+}
@@ -0,0 +1,137 @@
+package secure_backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*
+Clock for token expiry, not-before and age checks.
+e.g.) fixed clock for tests.
+*/
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct {
+}
+
+func (it systemClock) Now() time.Time {
+	return time.Now()
+}
+
+/*
+Time validation policy for 'exp', 'nbf' and 'iat' claims.
+nil is system clock, no leeway, and no lifetime limit.
+*/
+type tokenTimePolicy struct {
+	clock Clock
+
+	/*
+		Allowed clock skew.
+	*/
+	leeway time.Duration
+
+	/*
+		Maximum token lifetime(exp - iat), or zero.
+	*/
+	maxLifetime time.Duration
+}
+
+func (it *tokenTimePolicy) now() time.Time {
+	if it == nil || it.clock == nil {
+		return time.Now()
+	}
+	return it.clock.Now()
+}
+
+/*
+Returns time claim, or false if not found.
+*/
+func getTimeClaim(claims map[string]interface{}, key string) (time.Time, bool, error) {
+	value, ok := claims[key]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	switch value := value.(type) {
+	case float64:
+		return time.Unix(int64(value), 0), true, nil
+	case int64:
+		return time.Unix(value, 0), true, nil
+	case int:
+		return time.Unix(int64(value), 0), true, nil
+	case json.Number:
+		if t, err := value.Int64(); err == nil {
+			return time.Unix(t, 0), true, nil
+		}
+	}
+	return time.Time{}, true, newVerificationError(ErrMalformedToken, fmt.Sprintf("invalid JWT.%v", key), nil)
+}
+
+/*
+Validate 'exp', 'nbf' and 'iat' claims.
+Missing claims are not checked, caller checks required claims.
+*/
+func (it *tokenTimePolicy) validate(claims map[string]interface{}) error {
+	now := it.now()
+	var leeway time.Duration
+	if it != nil {
+		leeway = it.leeway
+	}
+
+	exp, hasExp, err := getTimeClaim(claims, "exp")
+	if err != nil {
+		return err
+	}
+	nbf, hasNbf, err := getTimeClaim(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	iat, hasIat, err := getTimeClaim(claims, "iat")
+	if err != nil {
+		return err
+	}
+
+	if hasExp && !now.Before(exp.Add(leeway)) {
+		return newVerificationError(ErrTokenExpired, fmt.Sprintf("invalid JWT.exp(%v)", exp), nil)
+	} else if hasNbf && now.Add(leeway).Before(nbf) {
+		return newVerificationError(ErrTokenNotValidYet, fmt.Sprintf("invalid JWT.nbf(%v)", nbf), nil)
+	} else if hasIat && now.Add(leeway).Before(iat) {
+		return newVerificationError(ErrTokenNotValidYet, fmt.Sprintf("JWT.iat is future(%v)", iat), nil)
+	}
+
+	return it.validateLifetime(claims)
+}
+
+/*
+Validate token lifetime('exp' - 'iat') only, without clock.
+Used for tokens that are already checked by other clock. e.g.) Firebase Admin SDK.
+*/
+func (it *tokenTimePolicy) validateLifetime(claims map[string]interface{}) error {
+	if it == nil || it.maxLifetime <= 0 {
+		return nil
+	}
+
+	exp, hasExp, err := getTimeClaim(claims, "exp")
+	if err != nil {
+		return err
+	}
+	iat, hasIat, err := getTimeClaim(claims, "iat")
+	if err != nil {
+		return err
+	}
+	if !hasExp || !hasIat {
+		return newVerificationError(ErrMalformedToken, "JWT.exp and JWT.iat are required for lifetime check", nil)
+	} else if exp.Sub(iat) > it.maxLifetime {
+		return newVerificationError(ErrMalformedToken, fmt.Sprintf("token lifetime exceeds maximum(%v)", exp.Sub(iat)), nil)
+	}
+	return nil
+}
+
+/*
+Returns current time by configured clock.
+*/
+func (it *securityContextImpl) now() time.Time {
+	return it.tokenTime.now()
+}
@@ -0,0 +1,134 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (it *fixedClock) Now() time.Time {
+	return it.now
+}
+
+func TestTokenTimePolicy_validate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	policy := &tokenTimePolicy{
+		clock:       &fixedClock{now: now},
+		leeway:      30 * time.Second,
+		maxLifetime: time.Hour,
+	}
+	unix := func(d time.Duration) float64 {
+		return float64(now.Add(d).Unix())
+	}
+
+	assert.NoError(t, policy.validate(map[string]interface{}{
+		"iat": unix(-time.Minute),
+		"nbf": unix(-time.Minute),
+		"exp": unix(time.Minute),
+	}))
+	// within leeway
+	assert.NoError(t, policy.validate(map[string]interface{}{
+		"iat": unix(10 * time.Second),
+		"nbf": unix(10 * time.Second),
+		"exp": unix(-10 * time.Second),
+	}))
+
+	for name, test := range map[string]struct {
+		claims map[string]interface{}
+		kind   error
+	}{
+		"expired":      {map[string]interface{}{"iat": unix(-time.Hour), "exp": unix(-time.Minute)}, ErrTokenExpired},
+		"nbf":          {map[string]interface{}{"iat": unix(0), "nbf": unix(time.Minute), "exp": unix(time.Hour)}, ErrTokenNotValidYet},
+		"future iat":   {map[string]interface{}{"iat": unix(time.Minute), "exp": unix(time.Hour)}, ErrTokenNotValidYet},
+		"too long":     {map[string]interface{}{"iat": unix(0), "exp": unix(2 * time.Hour)}, ErrMalformedToken},
+		"iat required": {map[string]interface{}{"exp": unix(time.Minute)}, ErrMalformedToken},
+		"invalid type": {map[string]interface{}{"iat": "now", "exp": unix(time.Minute)}, ErrMalformedToken},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := policy.validate(test.claims)
+			assert.True(t, errors.Is(err, test.kind), err)
+		})
+	}
+
+	// default, system clock
+	var empty *tokenTimePolicy
+	assert.NoError(t, empty.validate(map[string]interface{}{
+		"exp": float64(time.Now().Add(time.Minute).Unix()),
+	}))
+	assert.True(t, errors.Is(empty.validate(map[string]interface{}{
+		"exp": float64(time.Now().Add(-time.Second).Unix()),
+	}), ErrTokenExpired))
+}
+
+func TestFirebaseAuthVerifierImpl_Verify_clock(t *testing.T) {
+	ctx := context.Background()
+	owner := newOriginalTokenOwnerForTest(t)
+	clock := &fixedClock{now: time.Now().Add(-24 * time.Hour)}
+	owner.tokenTime = &tokenTimePolicy{clock: clock, leeway: time.Minute}
+	owner.gcp.serviceAccountPublicKeys.tokenTime = owner.tokenTime
+
+	verifier := owner.NewFirebaseAuthVerifier()
+	verifier.AcceptOriginalToken()
+
+	token, err := owner.gcp.tokenSigner.sign(ctx, jwt.MapClaims{
+		"iss": owner.gcp.clientEmail,
+		"sub": owner.gcp.clientEmail,
+		"aud": firebaseCustomTokenAudience,
+		"uid": "user-1",
+		"iat": clock.now.Unix(),
+		"nbf": clock.now.Unix(),
+		"exp": clock.now.Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+
+	// valid at configured clock, without sleep.
+	verified, err := verifier.Verify(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", verified.User.Id)
+
+	clock.now = clock.now.Add(time.Hour + 30*time.Second)
+	_, err = verifier.Verify(ctx, token)
+	assert.NoError(t, err)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err = verifier.Verify(ctx, token)
+	assert.True(t, errors.Is(err, ErrTokenExpired), err)
+
+	clock.now = clock.now.Add(-2 * time.Hour)
+	_, err = verifier.Verify(ctx, token)
+	assert.True(t, errors.Is(err, ErrTokenNotValidYet), err)
+}
+
+func TestFirebaseAuthVerifierImpl_newVerifiedFirebaseClientToken(t *testing.T) {
+	owner := newSecurityContextForTest()
+	owner.tokenTime = &tokenTimePolicy{maxLifetime: time.Hour}
+	verifier := owner.NewFirebaseAuthVerifier().(*firebaseAuthVerifierImpl)
+
+	// 'iat' is slightly future, accepted by SDK's clock skew.
+	now := time.Now()
+	verified, err := verifier.newVerifiedFirebaseClientToken(&auth.Token{
+		UID:      "user-1",
+		Audience: "example",
+		IssuedAt: now.Add(time.Second).Unix(),
+		Expires:  now.Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", verified.User.Id)
+
+	_, err = verifier.newVerifiedFirebaseClientToken(&auth.Token{
+		UID:      "user-1",
+		Audience: "example",
+		IssuedAt: now.Unix(),
+		Expires:  now.Add(2 * time.Hour).Unix(),
+	})
+	assert.True(t, errors.Is(err, ErrMalformedToken), err)
+}
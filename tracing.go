@@ -0,0 +1,46 @@
+package secure_backend
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+/*
+OpenTelemetry instrumentation name.
+*/
+const tracerName = "github.com/eaglesakura/go-secure-backend"
+
+/*
+Returns tracer by provider.
+If provider is nil, then returns no-op tracer.
+*/
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+/*
+End span with error.
+If err is not nil, then error class is set to 'error.class' attribute.
+*/
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(attribute.String("error.class", getErrorClass(err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (it *securityContextImpl) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if it.tracer == nil {
+		it.tracer = newTracer(nil)
+	}
+	return it.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
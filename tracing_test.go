@@ -0,0 +1,40 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_googlePublicKeyCache_parseJwt_span(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyCache := newGooglePublicKeyCache("http://127.0.0.1:0/unused", newSlogLogger(&Logger{}), newTracer(provider), &noopMetrics{})
+	keyCache.addOfflineKey(&googlePublicKey{kid: "test-kid", publicKey: &privateKey.PublicKey})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}).SignedString(privateKey)
+	assert.NoError(t, err)
+
+	_, _, err = keyCache.parseJwt(context.Background(), token)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "googlePublicKeyCache.parseJwt", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool("cache_hit", true))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("kid", "test-kid"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error.class", "token_expired"))
+}
@@ -0,0 +1,103 @@
+package secure_backend
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+/*
+Trusted reverse proxies(load balancers), for 'X-Forwarded-For' header.
+'X-Forwarded-For' is client controlled, so it is used only if remote address is trusted proxy.
+*/
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+/*
+New trusted proxies by CIDR or IP address.
+e.g.) "10.0.0.0/8", "130.211.0.0/22", "35.191.0.0/16"
+*/
+func NewTrustedProxies(cidrs ...string) (*TrustedProxies, error) {
+	result := &TrustedProxies{}
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy(%v): %w", cidr, err)
+		}
+		result.networks = append(result.networks, network)
+	}
+	return result, nil
+}
+
+func (it *TrustedProxies) contains(address string) bool {
+	if it == nil {
+		return false
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range it.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns client IP address of request.
+If remote address is trusted proxy, then right-most untrusted address in 'X-Forwarded-For'.
+Otherwise remote address.
+*/
+func (it *TrustedProxies) ClientIp(r *http.Request) string {
+	clientIp := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		clientIp = host
+	}
+	if !it.contains(clientIp) {
+		return clientIp
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// broken chain, use last trusted proxy.
+			return clientIp
+		} else if !it.contains(hop) {
+			return hop
+		}
+		clientIp = hop
+	}
+	return clientIp
+}
+
+/*
+HTTP middleware to set client IP address to context, see ClientIpFromContext().
+Use this before other middlewares, if service is behind load balancer.
+
+e.g.)
+
+	proxies, err := NewTrustedProxies("130.211.0.0/22", "35.191.0.0/16")
+	http.Handle("/api/", NewClientIpMiddleware(proxies)(NewGoogleApiKeyMiddleware(verifier)(apiHandler)))
+*/
+func NewClientIpMiddleware(proxies *TrustedProxies) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithClientIp(r.Context(), proxies.ClientIp(r))))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package secure_backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedProxies_ClientIp(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8", "192.0.2.1", "2001:db8::1")
+	assert.NoError(t, err)
+
+	for name, test := range map[string]struct {
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		"direct":            {"198.51.100.1:1234", nil, "198.51.100.1"},
+		"untrusted remote":  {"198.51.100.1:1234", []string{"203.0.113.1"}, "198.51.100.1"},
+		"trusted remote":    {"10.0.0.1:1234", []string{"203.0.113.1"}, "203.0.113.1"},
+		"spoofed left-most": {"10.0.0.1:1234", []string{"1.2.3.4, 203.0.113.1, 10.0.0.2"}, "203.0.113.1"},
+		"multiple headers":  {"192.0.2.1:1234", []string{"1.2.3.4", "203.0.113.1"}, "203.0.113.1"},
+		"all trusted":       {"10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		"broken":            {"10.0.0.1:1234", []string{"203.0.113.1, unknown"}, "10.0.0.1"},
+		"no header":         {"10.0.0.1:1234", nil, "10.0.0.1"},
+		"ipv6":              {"[2001:db8::1]:1234", []string{"2001:db8::2"}, "2001:db8::2"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, value := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, test.expected, proxies.ClientIp(r))
+		})
+	}
+
+	// nil is no trusted proxies.
+	var empty *TrustedProxies
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.1")
+	assert.Equal(t, "10.0.0.1", empty.ClientIp(r))
+
+	_, err = NewTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = NewTrustedProxies("proxy")
+	assert.Error(t, err)
+}
+
+func TestNewClientIpMiddleware(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	assert.NoError(t, err)
+	handler := NewClientIpMiddleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "203.0.113.1", ClientIpFromContext(r.Context()))
+		assert.Equal(t, "203.0.113.1", NewGoogleApiKeyVerifyRequest(r).ClientIp)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
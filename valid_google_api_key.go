@@ -1,12 +1,72 @@
 package secure_backend
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+)
 
 type validGoogleApiKey struct {
 	apiKey      string
 	serviceName string
+
+	/*
+		Client context.
+	*/
+	clientIp       string
+	referer        string
+	androidPackage string
+	androidCert    string
+	iosBundleId    string
 }
 
+/*
+Returns cache key, sha256 of all fields.
+Fields are encoded by JSON array, so different contexts never collide.
+*/
 func (it *validGoogleApiKey) cacheKey() string {
-	return fmt.Sprintf("%v:%v", it.serviceName, it.apiKey)
+	body, _ := json.Marshal([]string{
+		it.serviceName, it.apiKey,
+		it.clientIp, it.referer, it.androidPackage, it.androidCert, it.iosBundleId,
+	})
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+/*
+Returns referer without fragment, e.g.) "https://example.com/app/index.html?q=1".
+Path is kept for path restrictions of API Key, e.g.) "example.com/app/*".
+If referer is not URL, then empty.
+*/
+func getNormalizedReferer(referer string) string {
+	if len(referer) == 0 {
+		return ""
+	}
+	parsed, err := url.Parse(referer)
+	if err != nil || len(parsed.Scheme) == 0 || len(parsed.Host) == 0 {
+		return ""
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+/*
+Returns 'Service Control' operation labels.
+see) https://cloud.google.com/service-infrastructure/docs/service-control/reference/rest/v1/services/check
+*/
+func (it *validGoogleApiKey) operationLabels() map[string]string {
+	labels := map[string]string{}
+	put := func(key, value string) {
+		if len(value) > 0 {
+			labels[key] = value
+		}
+	}
+	put("servicecontrol.googleapis.com/caller_ip", it.clientIp)
+	put("servicecontrol.googleapis.com/referer", it.referer)
+	put("servicecontrol.googleapis.com/android_package_name", it.androidPackage)
+	put("servicecontrol.googleapis.com/android_cert_fingerprint", it.androidCert)
+	put("servicecontrol.googleapis.com/ios_bundle_id", it.iosBundleId)
+	return labels
 }
@@ -0,0 +1,174 @@
+package secure_backend
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Verification error kinds.
+All verifiers return an error that matches one of these by errors.Is().
+
+e.g.)
+
+	if errors.Is(err, ErrTokenExpired) {
+		// refresh token and retry.
+	}
+*/
+var (
+	// Token (or key) is expired.
+	ErrTokenExpired = errors.New("token expired")
+
+	// Token is not valid yet('nbf' or 'iat' is future).
+	ErrTokenNotValidYet = errors.New("token not valid yet")
+
+	// Token signature is invalid.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// Token 'aud' claim is not accepted.
+	ErrInvalidAudience = errors.New("invalid audience")
+
+	// Token 'iss' claim is not accepted.
+	ErrInvalidIssuer = errors.New("invalid issuer")
+
+	// Token is not a JWT, or required claims are missing.
+	ErrMalformedToken = errors.New("malformed token")
+
+	// Token signed by unknown key.
+	ErrUnknownKey = errors.New("unknown key")
+
+	// Token (or API Key) is revoked.
+	// e.g.) Firebase ID token, see FirebaseAuthVerifier.CheckRevoked().
+	ErrTokenRevoked = errors.New("token revoked")
+
+	// Signed request is replayed(nonce is already used).
+	ErrReplayedRequest = errors.New("replayed request")
+
+	// API Key is rejected.
+	ErrInvalidApiKey = errors.New("invalid api key")
+
+	// Verified principal is denied by authorization policy.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// Firebase user signed in anonymously.
+	// see) SignInRequirements
+	ErrAnonymousUser = errors.New("anonymous user")
+
+	// Firebase user email is not verified.
+	ErrEmailNotVerified = errors.New("email not verified")
+
+	// Firebase sign-in provider is not allowed.
+	ErrSignInProviderNotAllowed = errors.New("sign in provider not allowed")
+
+	// Firebase user email domain is not allowed.
+	ErrEmailDomainNotAllowed = errors.New("email domain not allowed")
+
+	// Token is valid, but re-authentication(recent login, second factor) is required.
+	// see) StepUpRequiredError
+	ErrStepUpRequired = errors.New("step up required")
+
+	// Backend service(public key repository, ServiceControl API, Firebase) is unavailable.
+	// This error is retryable.
+	ErrBackendUnavailable = errors.New("backend unavailable")
+)
+
+/*
+Typed verification error.
+
+Use errors.Is() to check error kind, and errors.As() to get detail.
+
+	var verifyErr *VerificationError
+	if errors.As(err, &verifyErr) && verifyErr.Retryable() {
+		// retry later.
+	}
+*/
+type VerificationError struct {
+	/*
+		Error kind.
+		One of ErrTokenExpired, ErrInvalidSignature, ...
+	*/
+	Kind error
+
+	/*
+		Error message.
+	*/
+	Message string
+
+	/*
+		Cause error, or nil.
+	*/
+	Cause error
+}
+
+func newVerificationError(kind error, message string, cause error) *VerificationError {
+	return &VerificationError{
+		Kind:    kind,
+		Message: message,
+		Cause:   cause,
+	}
+}
+
+func (it *VerificationError) Error() string {
+	if it.Cause != nil {
+		return fmt.Sprintf("%v: %v: %v", it.Kind, it.Message, it.Cause)
+	}
+	return fmt.Sprintf("%v: %v", it.Kind, it.Message)
+}
+
+func (it *VerificationError) Unwrap() []error {
+	if it.Cause != nil {
+		return []error{it.Kind, it.Cause}
+	}
+	return []error{it.Kind}
+}
+
+// Returns true if this error is temporary, caller can retry.
+func (it *VerificationError) Retryable() bool {
+	return it.Kind == ErrBackendUnavailable
+}
+
+/*
+Returns true if err is temporary, caller can retry.
+*/
+func IsRetryableError(err error) bool {
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	return false
+}
+
+/*
+Returns error class for logs, metrics and traces.
+e.g.) "token_expired", "invalid_signature", "backend_unavailable"
+*/
+func getErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, kind := range []error{
+		ErrTokenExpired,
+		ErrTokenNotValidYet,
+		ErrInvalidSignature,
+		ErrInvalidAudience,
+		ErrInvalidIssuer,
+		ErrMalformedToken,
+		ErrUnknownKey,
+		ErrTokenRevoked,
+		ErrReplayedRequest,
+		ErrInvalidApiKey,
+		ErrAnonymousUser,
+		ErrEmailNotVerified,
+		ErrSignInProviderNotAllowed,
+		ErrEmailDomainNotAllowed,
+		ErrStepUpRequired,
+		ErrPermissionDenied,
+		ErrBackendUnavailable,
+	} {
+		if errors.Is(err, kind) {
+			return strings.ReplaceAll(kind.Error(), " ", "_")
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,95 @@
+package secure_backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationError(t *testing.T) {
+	cause := errors.New("cause")
+	err := fmt.Errorf("wrapped: %w", newVerificationError(ErrBackendUnavailable, "download failed", cause))
+
+	assert.True(t, errors.Is(err, ErrBackendUnavailable))
+	assert.True(t, errors.Is(err, cause))
+	assert.False(t, errors.Is(err, ErrTokenExpired))
+	assert.True(t, IsRetryableError(err))
+
+	var verifyErr *VerificationError
+	assert.True(t, errors.As(err, &verifyErr))
+	assert.Equal(t, "download failed", verifyErr.Message)
+
+	assert.False(t, IsRetryableError(newVerificationError(ErrTokenExpired, "expired", nil)))
+	assert.False(t, IsRetryableError(errors.New("unknown")))
+}
+
+func TestGoogleApiKeyCheckError_Is(t *testing.T) {
+	invalid := &GoogleApiKeyCheckError{
+		Errors: []*GoogleApiKeyCheckErrorDetail{{Code: GoogleApiKeyErrorApiKeyInvalid}},
+	}
+	assert.True(t, errors.Is(invalid, ErrInvalidApiKey))
+	assert.False(t, IsRetryableError(invalid))
+
+	unavailable := &GoogleApiKeyCheckError{
+		Errors: []*GoogleApiKeyCheckErrorDetail{{Code: GoogleApiKeyErrorServiceUnavailable}},
+	}
+	assert.True(t, errors.Is(unavailable, ErrBackendUnavailable))
+	assert.True(t, IsRetryableError(unavailable))
+}
+
+func Test_googlePublicKeyCache_parseJwt_errors(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	keyCache := newGooglePublicKeyCache("http://127.0.0.1:0/unused", newSlogLogger(&Logger{}), newTracer(nil), &noopMetrics{})
+	keyCache.addOfflineKey(&googlePublicKey{kid: "test", publicKey: &privateKey.PublicKey})
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+		assert.NoError(t, err)
+		return token
+	}
+
+	_, parsed, err := keyCache.parseJwt(context.Background(), sign(jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}))
+	assert.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	_, _, err = keyCache.parseJwt(context.Background(), sign(jwt.MapClaims{
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}))
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+
+	_, _, err = keyCache.parseJwt(context.Background(), sign(jwt.MapClaims{
+		"nbf": time.Now().Add(time.Hour).Unix(),
+	}))
+	assert.True(t, errors.Is(err, ErrTokenNotValidYet))
+
+	_, _, err = keyCache.parseJwt(context.Background(), "this is not jwt")
+	assert.True(t, errors.Is(err, ErrMalformedToken))
+}
+
+func Test_newFirebaseAuthVerificationError(t *testing.T) {
+	assert.True(t, errors.Is(newFirebaseAuthVerificationError(
+		errors.New("ID token has expired at: 1234")), ErrTokenExpired))
+	// VerifyIDTokenAndCheckRevoked(), see FirebaseAuthVerifier.CheckRevoked().
+	revoked := newFirebaseAuthVerificationError(errors.New("ID token has been revoked"))
+	assert.True(t, errors.Is(revoked, ErrTokenRevoked))
+	assert.False(t, IsRetryableError(revoked))
+	assert.True(t, errors.Is(newFirebaseAuthVerificationError(
+		errors.New("ID token has invalid 'aud' (audience) claim; expected \"a\" but got \"b\"")), ErrInvalidAudience))
+	assert.True(t, errors.Is(newFirebaseAuthVerificationError(
+		errors.New("failed to verify token signature")), ErrInvalidSignature))
+	assert.True(t, errors.Is(newFirebaseAuthVerificationError(
+		errors.New("invalid response (500) while retrieving public keys: error")), ErrBackendUnavailable))
+	assert.True(t, errors.Is(newFirebaseAuthVerificationError(
+		errors.New("incorrect number of segments; see https://firebase.google.com/docs/auth/admin/verify-id-tokens for details")), ErrMalformedToken))
+}
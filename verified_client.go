@@ -0,0 +1,30 @@
+package secure_backend
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+Verified signed request client.
+*/
+type VerifiedClient struct {
+	/*
+		Client id.
+	*/
+	ClientId string
+
+	/*
+		Signed time.
+	*/
+	SignedAt time.Time
+
+	/*
+		Request nonce.
+	*/
+	Nonce string
+}
+
+func (it *VerifiedClient) String() string {
+	return fmt.Sprintf("VerifiedClient(%v)", it.ClientId)
+}
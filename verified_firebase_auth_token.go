@@ -1,8 +1,6 @@
 package secure_backend
 
 import (
-	"errors"
-	"fmt"
 	"time"
 )
 
@@ -20,52 +18,57 @@ type VerifiedFirebaseAuthToken struct {
 	*/
 	ExpireAt time.Time
 
+	/*
+		User authentication time('auth_time' claim), or zero.
+	*/
+	AuthTime time.Time
+
+	/*
+		Second factor of sign-in('firebase.sign_in_second_factor' claim), or empty.
+		e.g.) "phone", "totp"
+	*/
+	SecondFactor string
+
+	/*
+		Firebase(GCP) Project ID of token.
+		Project of issuer for original token, see TrustedIssuer.ProjectId.
+	*/
+	ProjectId string
+
+	/*
+		Granted scopes of original token(e.g. exchanged token).
+		nil for Firebase ID token, empty if original token has no granted scope.
+	*/
+	Scopes []string
+
+	/*
+		Delegation chain of exchanged token('act' claim), nearest actor first.
+		e.g.) []string{"edge@example.iam.gserviceaccount.com"}
+	*/
+	Actors []string
+
 	/*
 		JWT Claims.
 	*/
-	Claims map[string]interface{}
+	Claims TokenClaims
 }
 
-func (it *VerifiedFirebaseAuthToken) GetIntClaim(key string) (int64, error) {
-	v, ok := it.Claims[key]
-	if !ok {
-		return 0, errors.New(fmt.Sprintf("claim key not found[%v]", key))
-	}
+func (it *VerifiedFirebaseAuthToken) GetUserId() string {
+	return it.User.Id
+}
 
-	switch v.(type) {
-	case int64:
-		return v.(int64), nil
-	case int:
-		return int64(v.(int)), nil
-	case float64:
-		return int64(v.(float64)), nil
-	}
+func (it *VerifiedFirebaseAuthToken) GetClaims() map[string]interface{} {
+	return it.Claims
+}
 
-	return 0, errors.New(fmt.Sprintf("claim type error[%v]", key))
+func (it *VerifiedFirebaseAuthToken) GetIntClaim(key string) (int64, error) {
+	return it.Claims.GetIntClaim(key)
 }
 
 func (it *VerifiedFirebaseAuthToken) GetStringClaim(key string) (string, error) {
-	v, ok := it.Claims[key]
-	if !ok {
-		return "", errors.New(fmt.Sprintf("claim key not found[%v]", key))
-	}
-	return fmt.Sprintf("%v", v), nil
+	return it.Claims.GetStringClaim(key)
 }
 
 func (it *VerifiedFirebaseAuthToken) GetFloatClaim(key string) (float64, error) {
-	v, ok := it.Claims[key]
-	if !ok {
-		return 0, errors.New(fmt.Sprintf("claim key not found[%v]", key))
-	}
-
-	switch v.(type) {
-	case int64:
-		return float64(v.(int64)), nil
-	case int:
-		return float64(v.(int)), nil
-	case float64:
-		return v.(float64), nil
-	}
-
-	return 0, errors.New(fmt.Sprintf("claim type error[%v]", key))
+	return it.Claims.GetFloatClaim(key)
 }
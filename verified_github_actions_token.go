@@ -0,0 +1,59 @@
+package secure_backend
+
+/*
+Verified GitHub Actions OIDC token.
+Claims are also available by GetClaims().
+
+see) https://docs.github.com/en/actions/deployment/security-hardening-your-deployments/about-security-hardening-with-openid-connect#understanding-the-oidc-token
+*/
+type VerifiedGithubActionsToken struct {
+	*VerifiedOidcToken
+
+	/*
+		e.g.) "octo-org/octo-repo"
+	*/
+	Repository string
+
+	/*
+		e.g.) "octo-org"
+	*/
+	RepositoryOwner string
+
+	/*
+		e.g.) "refs/heads/main"
+	*/
+	Ref string
+
+	/*
+		Deployment environment, or empty.
+		e.g.) "production"
+	*/
+	Environment string
+
+	/*
+		Reusable workflow ref.
+		e.g.) "octo-org/octo-automation/.github/workflows/deploy.yml@refs/heads/main"
+	*/
+	JobWorkflowRef string
+
+	/*
+		User who triggered workflow.
+	*/
+	Actor string
+}
+
+func newVerifiedGithubActionsToken(token *VerifiedOidcToken) *VerifiedGithubActionsToken {
+	claim := func(key string) string {
+		value, _ := token.Claims[key].(string)
+		return value
+	}
+	return &VerifiedGithubActionsToken{
+		VerifiedOidcToken: token,
+		Repository:        claim("repository"),
+		RepositoryOwner:   claim("repository_owner"),
+		Ref:               claim("ref"),
+		Environment:       claim("environment"),
+		JobWorkflowRef:    claim("job_workflow_ref"),
+		Actor:             claim("actor"),
+	}
+}
@@ -0,0 +1,30 @@
+package secure_backend
+
+import "fmt"
+
+/*
+Verified API Key Data.
+*/
+type VerifiedGoogleApiKey struct {
+	/*
+		'Service Control' service name.
+		e.g.) "your-gcp-project.appspot.com"
+	*/
+	ServiceName string
+
+	/*
+		API Key owner's GCP project number.
+		0 if ServiceControl API not returned consumer info.
+	*/
+	ConsumerProjectNumber int64
+
+	/*
+		API Key owner in local API Key file.
+		Empty if API Key verified by ServiceControl API.
+	*/
+	Owner string
+}
+
+func (it *VerifiedGoogleApiKey) String() string {
+	return fmt.Sprintf("VerifiedGoogleApiKey(%v, project=%v)", it.ServiceName, it.ConsumerProjectNumber)
+}
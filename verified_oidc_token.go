@@ -0,0 +1,50 @@
+package secure_backend
+
+import (
+	"time"
+)
+
+/*
+Verified OpenID Connect token.
+*/
+type VerifiedOidcToken struct {
+	/*
+		'sub' claim.
+	*/
+	Subject string
+
+	/*
+		'iss' claim.
+	*/
+	Issuer string
+
+	/*
+		Token expire time.
+	*/
+	ExpireAt time.Time
+
+	/*
+		JWT Claims.
+	*/
+	Claims TokenClaims
+}
+
+func (it *VerifiedOidcToken) GetUserId() string {
+	return it.Subject
+}
+
+func (it *VerifiedOidcToken) GetClaims() map[string]interface{} {
+	return it.Claims
+}
+
+func (it *VerifiedOidcToken) GetIntClaim(key string) (int64, error) {
+	return it.Claims.GetIntClaim(key)
+}
+
+func (it *VerifiedOidcToken) GetStringClaim(key string) (string, error) {
+	return it.Claims.GetStringClaim(key)
+}
+
+func (it *VerifiedOidcToken) GetFloatClaim(key string) (float64, error) {
+	return it.Claims.GetFloatClaim(key)
+}
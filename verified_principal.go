@@ -0,0 +1,13 @@
+package secure_backend
+
+/*
+Verified principal, for authorization policy.
+All verified token types implement this interface.
+*/
+type VerifiedPrincipal interface {
+	// Returns verified user(subject) id.
+	GetUserId() string
+
+	// Returns all verified claims.
+	GetClaims() map[string]interface{}
+}
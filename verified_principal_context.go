@@ -0,0 +1,124 @@
+package secure_backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type verifiedPrincipalContextKey struct{}
+
+type verifiedGoogleApiKeyContextKey struct{}
+
+type policyRequestContextKey struct{}
+
+type clientIpContextKey struct{}
+
+/*
+Returns new context with verified principal.
+*/
+func WithVerifiedPrincipal(ctx context.Context, principal VerifiedPrincipal) context.Context {
+	return context.WithValue(ctx, verifiedPrincipalContextKey{}, principal)
+}
+
+/*
+Returns verified principal by middleware(interceptor), or nil.
+*/
+func VerifiedPrincipalFromContext(ctx context.Context) VerifiedPrincipal {
+	principal, _ := ctx.Value(verifiedPrincipalContextKey{}).(VerifiedPrincipal)
+	return principal
+}
+
+/*
+Returns verified Firebase Auth token by middleware(interceptor), or nil.
+*/
+func VerifiedFirebaseAuthTokenFromContext(ctx context.Context) *VerifiedFirebaseAuthToken {
+	token, _ := VerifiedPrincipalFromContext(ctx).(*VerifiedFirebaseAuthToken)
+	return token
+}
+
+/*
+Returns verified OpenID Connect token by middleware, or nil.
+*/
+func VerifiedOidcTokenFromContext(ctx context.Context) *VerifiedOidcToken {
+	token, _ := VerifiedPrincipalFromContext(ctx).(*VerifiedOidcToken)
+	return token
+}
+
+/*
+Returns verified GitHub Actions token by middleware, or nil.
+*/
+func VerifiedGithubActionsTokenFromContext(ctx context.Context) *VerifiedGithubActionsToken {
+	token, _ := VerifiedPrincipalFromContext(ctx).(*VerifiedGithubActionsToken)
+	return token
+}
+
+/*
+Returns new context with verified API Key.
+*/
+func WithVerifiedGoogleApiKey(ctx context.Context, apiKey *VerifiedGoogleApiKey) context.Context {
+	return context.WithValue(ctx, verifiedGoogleApiKeyContextKey{}, apiKey)
+}
+
+/*
+Returns verified API Key by middleware, or nil.
+*/
+func VerifiedGoogleApiKeyFromContext(ctx context.Context) *VerifiedGoogleApiKey {
+	apiKey, _ := ctx.Value(verifiedGoogleApiKeyContextKey{}).(*VerifiedGoogleApiKey)
+	return apiKey
+}
+
+/*
+Returns new context with request attributes for policy.
+e.g.) {"method": "GET", "path": "/tenants/example", "params": {"tenant": "example"}}
+*/
+func WithPolicyRequest(ctx context.Context, attributes map[string]interface{}) context.Context {
+	return context.WithValue(ctx, policyRequestContextKey{}, attributes)
+}
+
+/*
+Returns request attributes for policy, or nil.
+*/
+func PolicyRequestFromContext(ctx context.Context) map[string]interface{} {
+	attributes, _ := ctx.Value(policyRequestContextKey{}).(map[string]interface{})
+	return attributes
+}
+
+/*
+Returns new context with client IP address, for audit.
+*/
+func WithClientIp(ctx context.Context, clientIp string) context.Context {
+	return context.WithValue(ctx, clientIpContextKey{}, clientIp)
+}
+
+/*
+Returns client IP address, or empty.
+*/
+func ClientIpFromContext(ctx context.Context) string {
+	clientIp, _ := ctx.Value(clientIpContextKey{}).(string)
+	return clientIp
+}
+
+/*
+Returns token from 'Bearer' authorization value, or empty.
+*/
+func getBearerToken(authorization string) string {
+	const prefix = "bearer "
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(prefix):])
+}
+
+/*
+Returns HTTP status code for verification(or authorization) error.
+*/
+func getHttpStatusCode(err error) int {
+	if errors.Is(err, ErrPermissionDenied) {
+		return http.StatusForbidden
+	} else if IsRetryableError(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusUnauthorized
+}